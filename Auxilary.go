@@ -2,6 +2,7 @@ package gorph
 
 import (
 	"image"
+	"math"
 )
 
 // Float64Point represents a double precision point. It is capable of representing
@@ -18,6 +19,20 @@ func ToFloat64Point(pt image.Point) Float64Point {
 	return Float64Point{float64(pt.X), float64(pt.Y)}
 }
 
+// roundFloat64Point rounds a Float64Point to the nearest image.Point.
+func roundFloat64Point(pt Float64Point) image.Point {
+	return pt.ToImagePoint(RoundNearest)
+}
+
+// roundFloat64Points rounds every Float64Point to the nearest image.Point.
+func roundFloat64Points(pts []Float64Point) []image.Point {
+	result := make([]image.Point, len(pts))
+	for i := range pts {
+		result[i] = roundFloat64Point(pts[i])
+	}
+	return result
+}
+
 // MaxInt is a convenience function that returns the larger of two integers.
 func MaxInt(x, y int) int {
 	if x > y {
@@ -25,3 +40,8 @@ func MaxInt(x, y int) int {
 	}
 	return y
 }
+
+// clampUnit restricts a value to the range [0.0, 1.0].
+func clampUnit(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
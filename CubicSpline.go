@@ -0,0 +1,332 @@
+package gorph
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// axisCurve is a line that can be queried for the points at which it rises through
+// a given x or y value, as used by the two passes of the mesh warp.
+type axisCurve interface {
+	InterpolatePointsAtX(xValue float64) ([]Float64Point, error)
+	InterpolatePointsAtY(yValue float64) ([]Float64Point, error)
+}
+
+// CubicSpline is a piecewise cubic parametric curve stored as the polynomial
+// coefficients of its segments. Unlike the points returned by
+// CubicCatmullRomInterpolation, it can be evaluated exactly at any parameter and
+// solved exactly for the points at a given x or y value, at any resolution.
+//
+// The parameter of a spline runs from 0 at its first point to Segments() at its
+// last, with segment i spanning [i, i+1].
+type CubicSpline struct {
+	points   []Float64Point
+	segments []cubicSegment
+	xIndex   splineAxisIndex
+	yIndex   splineAxisIndex
+}
+
+// cubicSegment holds the coefficients of x(u) and y(u) = c[0] + c[1]u + c[2]u^2 +
+// c[3]u^3 for the local parameter u in [0, 1].
+type cubicSegment struct {
+	x [4]float64
+	y [4]float64
+}
+
+// splineAxisIndex indexes the monotone pieces of the segments along which one
+// coordinate of a spline rises, so InterpolatePointsAtX and InterpolatePointsAtY
+// solve only the segments that reach the value. It is built on the first query.
+type splineAxisIndex struct {
+	once   sync.Once
+	pieces []splinePiece
+	rising *risingIntervalIndex
+}
+
+// splinePiece is the range [lo, hi] of the local parameter of a segment along which
+// a coordinate rises.
+type splinePiece struct {
+	segment int
+	lo      float64
+	hi      float64
+}
+
+// hermiteSegment converts the end points and tangents of a cubic Hermite segment to
+// polynomial coefficients.
+func hermiteSegment(p1, p2, m1, m2 Float64Point) cubicSegment {
+	coefficients := func(v1, v2, t1, t2 float64) [4]float64 {
+		return [4]float64{v1, t1, -3*v1 - 2*t1 + 3*v2 - t2, 2*v1 + t1 - 2*v2 + t2}
+	}
+	return cubicSegment{coefficients(p1.X, p2.X, m1.X, m2.X), coefficients(p1.Y, p2.Y, m1.Y, m2.Y)}
+}
+
+// NewCatmullRomSpline computes the Catmull-Rom spline through a given set of points,
+// with the alpha value, end conditions and closure of the options. It is the same
+// curve sampled by CubicCatmullRomInterpolationWith. If two points are passed in,
+// the spline is the straight segment between them.
+func NewCatmullRomSpline(points []Float64Point, opts CatmullRomOptions) (*CubicSpline, error) {
+	nPoints := len(points)
+	if nPoints < 2 {
+		return nil, errors.New("NewCatmullRomSpline: Less than two points passed in")
+	}
+	if opts.Alpha < 0 || opts.Alpha > 1 {
+		return nil, errors.New("NewCatmullRomSpline: Alpha must be in the range of [0.0, 1.0]")
+	}
+	if nPoints == 2 && !opts.Closed {
+		chord := Float64Point{points[1].X - points[0].X, points[1].Y - points[0].Y}
+		return &CubicSpline{points: []Float64Point{points[0], points[1]}, segments: []cubicSegment{hermiteSegment(points[0], points[1], chord, chord)}}, nil
+	}
+	controls, err := catmullRomControls(points, opts)
+	if err != nil {
+		return nil, err
+	}
+	nSegments := len(controls) - 3
+	spline := &CubicSpline{points: controls[1 : nSegments+2], segments: make([]cubicSegment, 0, nSegments)}
+	for i := 0; i < nSegments; i++ {
+		p0, p1, p2, p3 := controls[i], controls[i+1], controls[i+2], controls[i+3]
+		d01 := knotInterval(p0, p1, opts.Alpha)
+		d12 := knotInterval(p1, p2, opts.Alpha)
+		d23 := knotInterval(p2, p3, opts.Alpha)
+		// Tangents of the non-uniform Catmull-Rom segment, scaled to the segment's
+		// local parameter
+		tangent := func(a, b, c Float64Point, dab, dbc float64, coordinate func(Float64Point) float64) float64 {
+			return d12 * ((coordinate(b)-coordinate(a))/dab - (coordinate(c)-coordinate(a))/(dab+dbc) + (coordinate(c)-coordinate(b))/dbc)
+		}
+		getX := func(pt Float64Point) float64 { return pt.X }
+		getY := func(pt Float64Point) float64 { return pt.Y }
+		m1 := Float64Point{tangent(p0, p1, p2, d01, d12, getX), tangent(p0, p1, p2, d01, d12, getY)}
+		m2 := Float64Point{tangent(p1, p2, p3, d12, d23, getX), tangent(p1, p2, p3, d12, d23, getY)}
+		spline.segments = append(spline.segments, hermiteSegment(p1, p2, m1, m2))
+	}
+	return spline, nil
+}
+
+// NewMonotoneCubicSpline computes the monotone cubic spline through a given set of
+// points, as sampled by MonotoneCubicInterpolation. The parameter of each segment
+// is proportional to the sweep axis.
+func NewMonotoneCubicSpline(points []Float64Point, vertical bool) (*CubicSpline, error) {
+	if len(points) < 2 {
+		return nil, errors.New("NewMonotoneCubicSpline: Less than two points passed in")
+	}
+	curve, err := newMonotoneCubic(points, vertical)
+	if err != nil {
+		return nil, errors.New("NewMonotoneCubicSpline: " + err.Error())
+	}
+	spline := &CubicSpline{points: append([]Float64Point(nil), points...), segments: make([]cubicSegment, 0, len(points)-1)}
+	for i := 0; i < len(points)-1; i++ {
+		h := curve.knots[i+1] - curve.knots[i]
+		m1 := Float64Point{h, curve.slopes[i] * h}
+		m2 := Float64Point{h, curve.slopes[i+1] * h}
+		if vertical {
+			m1 = Float64Point{curve.slopes[i] * h, h}
+			m2 = Float64Point{curve.slopes[i+1] * h, h}
+		}
+		spline.segments = append(spline.segments, hermiteSegment(points[i], points[i+1], m1, m2))
+	}
+	return spline, nil
+}
+
+// Segments returns the number of cubic segments of the spline.
+func (s *CubicSpline) Segments() int {
+	return len(s.segments)
+}
+
+// segmentAt splits the parameter t, clamped to [0, Segments()], into a segment index
+// and the local parameter within that segment.
+func (s *CubicSpline) segmentAt(t float64) (int, float64) {
+	n := len(s.segments)
+	if t <= 0 {
+		return 0, 0
+	} else if t >= float64(n) {
+		return n - 1, 1
+	}
+	i := int(math.Floor(t))
+	return i, t - float64(i)
+}
+
+// At evaluates the spline at the parameter t, which is clamped to [0, Segments()].
+func (s *CubicSpline) At(t float64) Float64Point {
+	i, u := s.segmentAt(t)
+	if u == 0 {
+		return s.points[i]
+	} else if u == 1 {
+		return s.points[i+1]
+	}
+	return Float64Point{evalCubic(s.segments[i].x, u), evalCubic(s.segments[i].y, u)}
+}
+
+// Derivative evaluates the first derivative of the spline with respect to its
+// parameter at t, which is clamped to [0, Segments()].
+func (s *CubicSpline) Derivative(t float64) Float64Point {
+	i, u := s.segmentAt(t)
+	return Float64Point{evalCubicDerivative(s.segments[i].x, u), evalCubicDerivative(s.segments[i].y, u)}
+}
+
+// InterpolatePointsAtX finds the points at which the spline rises through the given
+// x value, that is where x increases along the spline. The last point of the spline
+// is included if its x equals the value. Returns an error if there are none.
+func (s *CubicSpline) InterpolatePointsAtX(xValue float64) ([]Float64Point, error) {
+	points := s.pointsAt(xValue, false)
+	if len(points) == 0 {
+		return nil, errors.New("InterpolatePointsAtX: No points interpolated for value = " + strconv.FormatFloat(xValue, 'g', 8, 64))
+	}
+	return points, nil
+}
+
+// InterpolatePointsAtY finds the points at which the spline rises through the given
+// y value, that is where y increases along the spline. The last point of the spline
+// is included if its y equals the value. Returns an error if there are none.
+func (s *CubicSpline) InterpolatePointsAtY(yValue float64) ([]Float64Point, error) {
+	points := s.pointsAt(yValue, true)
+	if len(points) == 0 {
+		return nil, errors.New("InterpolatePointsAtY: No points interpolated for value = " + strconv.FormatFloat(yValue, 'g', 8, 64))
+	}
+	return points, nil
+}
+
+// axisIndex returns the index of the rising pieces of the x or y coordinate,
+// building it on first use. Each segment is split at the roots of its derivative
+// into monotone pieces, and every rising piece is indexed by the half-open range
+// [start, end) of the coordinate over it. The end points of the segments take their
+// exact values from the spline's points, so a value at a joint lies in exactly one
+// piece.
+func (s *CubicSpline) axisIndex(useY bool) *splineAxisIndex {
+	index := &s.xIndex
+	if useY {
+		index = &s.yIndex
+	}
+	index.once.Do(func() {
+		coordinate := func(pt Float64Point) float64 {
+			if useY {
+				return pt.Y
+			}
+			return pt.X
+		}
+		var lo, hi []float64
+		var buffer [4]float64
+		for i, segment := range s.segments {
+			c := segment.x
+			if useY {
+				c = segment.y
+			}
+			bounds := append(appendQuadraticRootsInUnit(append(buffer[:0], 0), c[1], 2*c[2], 3*c[3]), 1)
+			for j := 1; j < len(bounds); j++ {
+				fLo, fHi := evalCubic(c, bounds[j-1]), evalCubic(c, bounds[j])
+				if bounds[j-1] == 0 {
+					fLo = coordinate(s.points[i])
+				}
+				if bounds[j] == 1 {
+					fHi = coordinate(s.points[i+1])
+				}
+				if fHi > fLo {
+					index.pieces = append(index.pieces, splinePiece{i, bounds[j-1], bounds[j]})
+					lo = append(lo, fLo)
+					hi = append(hi, fHi)
+				}
+			}
+		}
+		index.rising = newRisingIntervalIndex(lo, hi)
+	})
+	return index
+}
+
+// pointsAt finds the parameters at which the coordinate rises through value by
+// looking up the rising pieces that span it, and solving each with Newton's method
+// safeguarded by bisection.
+func (s *CubicSpline) pointsAt(value float64, useY bool) []Float64Point {
+	index := s.axisIndex(useY)
+	var points []Float64Point
+	var buffer [4]int
+	for _, p := range index.rising.appendPiecesAt(buffer[:0], value) {
+		piece := index.pieces[p]
+		segment := s.segments[piece.segment]
+		c := segment.x
+		if useY {
+			c = segment.y
+		}
+		u := solveCubic(c, value, piece.lo, piece.hi)
+		pt := Float64Point{evalCubic(segment.x, u), evalCubic(segment.y, u)}
+		if useY {
+			pt.Y = value
+		} else {
+			pt.X = value
+		}
+		points = append(points, pt)
+	}
+	if last := s.points[len(s.points)-1]; (useY && last.Y == value) || (!useY && last.X == value) {
+		points = append(points, last)
+	}
+	return points
+}
+
+func evalCubic(c [4]float64, u float64) float64 {
+	return c[0] + u*(c[1]+u*(c[2]+u*c[3]))
+}
+
+func evalCubicDerivative(c [4]float64, u float64) float64 {
+	return c[1] + u*(2*c[2]+u*3*c[3])
+}
+
+// appendQuadraticRootsInUnit appends the roots of a + bu + cu^2 strictly inside
+// (0, 1) to dst, in increasing order.
+func appendQuadraticRootsInUnit(dst []float64, a, b, c float64) []float64 {
+	var roots [2]float64
+	nRoots := 0
+	if c == 0 {
+		if b != 0 {
+			roots[0] = -a / b
+			nRoots = 1
+		}
+	} else if discriminant := b*b - 4*a*c; discriminant >= 0 {
+		sqrtDisc := math.Sqrt(discriminant)
+		// Avoid cancellation by computing the larger root first
+		q := -0.5 * (b + math.Copysign(sqrtDisc, b))
+		roots[0] = q / c
+		nRoots = 1
+		if q != 0 {
+			roots[1] = a / q
+			nRoots = 2
+		}
+		if nRoots == 2 && roots[0] > roots[1] {
+			roots[0], roots[1] = roots[1], roots[0]
+		}
+	}
+	for _, root := range roots[:nRoots] {
+		if root > 0 && root < 1 {
+			dst = append(dst, root)
+		}
+	}
+	return dst
+}
+
+// solveCubic finds u in [lo, hi] where the cubic, rising over that interval, equals
+// value.
+func solveCubic(c [4]float64, value, lo, hi float64) float64 {
+	u := (lo + hi) / 2
+	for iteration := 0; iteration < 64; iteration++ {
+		f := evalCubic(c, u) - value
+		if f == 0 {
+			return u
+		} else if f < 0 {
+			lo = u
+		} else {
+			hi = u
+		}
+		if hi-lo < 1e-15 {
+			break
+		}
+		// Take the Newton step if it stays within the bracket, otherwise bisect
+		next := (lo + hi) / 2
+		if derivative := evalCubicDerivative(c, u); derivative > 0 {
+			if newton := u - f/derivative; newton > lo && newton < hi {
+				next = newton
+			}
+		}
+		if math.Abs(next-u) < 1e-15 {
+			return next
+		}
+		u = next
+	}
+	return u
+}
@@ -0,0 +1,179 @@
+package gorph
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestCatmullRomSplineMatchesInterpolation(t *testing.T) {
+	points := []Float64Point{{0, 0}, {1, 1}, {2, 0}}
+	sampled, err := CubicCatmullRomInterpolation(points, 0.5, 30)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	spline, err := NewCatmullRomSpline(points, CatmullRomOptions{Alpha: 0.5})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, spline.Segments(), 2, "Incorrect number of segments")
+	// Each segment is sampled in 15 steps
+	for j, pt := range sampled {
+		AssertEqualsFloat64PointTolerance(t, spline.At(float64(j)/15), pt, .000001, "Spline differs from sampled curve")
+	}
+	AssertEqualsFloat64Point(t, spline.At(-1), points[0], "Parameter not clamped at start")
+	AssertEqualsFloat64Point(t, spline.At(5), points[2], "Parameter not clamped at end")
+}
+
+func TestCatmullRomSplineClosed(t *testing.T) {
+	points := []Float64Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}
+	sampled, err := CubicCatmullRomInterpolationWith(points, CatmullRomOptions{Alpha: 0.5, Closed: true}, 80)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	spline, err := NewCatmullRomSpline(points, CatmullRomOptions{Alpha: 0.5, Closed: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, spline.Segments(), 4, "Incorrect number of segments")
+	for j, pt := range sampled {
+		AssertEqualsFloat64PointTolerance(t, spline.At(float64(j)/20), pt, .000001, "Spline differs from sampled curve")
+	}
+}
+
+func TestMonotoneCubicSplineMatchesInterpolation(t *testing.T) {
+	points := []Float64Point{{0, 0}, {50, 5}, {0, 6}, {0, 40}}
+	sampled, err := MonotoneCubicInterpolation(points, true, 81)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	spline, err := NewMonotoneCubicSpline(points, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, pt := range sampled {
+		found, err := spline.InterpolatePointsAtY(pt.Y)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		AssertEqualsInt(t, len(found), 1, "Monotone spline is not single-valued")
+		AssertEqualsFloat64PointTolerance(t, found[0], pt, .000001, "Spline differs from sampled curve")
+	}
+}
+
+func TestCubicSplineInterpolatePointsAtY(t *testing.T) {
+	spline, err := NewCatmullRomSpline([]Float64Point{{0, 0}, {1, 4}, {2, 8}}, CatmullRomOptions{Alpha: 0.5})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// The joint between the segments is found exactly once
+	pts, err := spline.InterpolatePointsAtY(4)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(pts), 1, "Joint found more than once")
+	AssertEqualsFloat64PointTolerance(t, pts[0], Float64Point{1, 4}, .000001, "Joint incorrect")
+	pts, err = spline.InterpolatePointsAtY(8)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(pts), 1, "Last point not found")
+	pts, err = spline.InterpolatePointsAtX(0.5)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, pts[0], Float64Point{0.5, 2}, .000001, "Straight spline is not straight")
+	_, err = spline.InterpolatePointsAtY(9)
+	if err == nil {
+		t.Error("Expected error for value beyond the spline")
+	}
+}
+
+func TestCubicSplineInterpolatePointsAtYFold(t *testing.T) {
+	spline, err := NewCatmullRomSpline([]Float64Point{{0, 0}, {1, 10}, {2, 5}, {3, 20}}, CatmullRomOptions{Alpha: 0.5})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pts, err := spline.InterpolatePointsAtY(7)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// Rising through y=7 before and after the fold, but not on the way down
+	AssertEqualsInt(t, len(pts), 2, "Incorrect number of rising points")
+	for _, pt := range pts {
+		AssertEqualsFloat64PointTolerance(t, Float64Point{0, pt.Y}, Float64Point{0, 7}, .000001, "Point not on value")
+	}
+}
+
+func TestAppendQuadraticRootsInUnit(t *testing.T) {
+	// (u - 0.25)(u - 0.75) = u^2 - u + 0.1875
+	AssertEqualsFloat64Slice(t, appendQuadraticRootsInUnit(nil, 0.1875, -1, 1), []float64{0.25, 0.75}, "Incorrect roots")
+	AssertEqualsFloat64Slice(t, appendQuadraticRootsInUnit(nil, -0.5, 1, 0), []float64{0.5}, "Incorrect linear root")
+	AssertEqualsInt(t, len(appendQuadraticRootsInUnit(nil, 1, 0, 1)), 0, "Expected no roots")
+}
+
+func TestCubicSplinePointsAtAllocations(t *testing.T) {
+	spline, err := NewCatmullRomSpline([]Float64Point{{0, 0}, {3, 2}, {1, 5}, {4, 7}, {2, 10}}, CatmullRomOptions{Alpha: 0.5})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// Only the slice of points found is allocated, not the bounds of every segment
+	allocs := testing.AllocsPerRun(100, func() {
+		spline.pointsAt(6, true)
+	})
+	if allocs > 1 {
+		t.Error("pointsAt allocated " + strconv.FormatFloat(allocs, 'g', -1, 64) + " times per query")
+	}
+}
+
+// scanSplinePointsAtY solves every monotone piece of every segment, as the index
+// replaces.
+func scanSplinePointsAtY(s *CubicSpline, value float64) (points []Float64Point) {
+	for i, segment := range s.segments {
+		c := segment.y
+		bounds := append(appendQuadraticRootsInUnit([]float64{0}, c[1], 2*c[2], 3*c[3]), 1)
+		for j := 1; j < len(bounds); j++ {
+			lo, hi := bounds[j-1], bounds[j]
+			fLo, fHi := evalCubic(c, lo), evalCubic(c, hi)
+			if lo == 0 {
+				fLo = s.points[i].Y
+			}
+			if hi == 1 {
+				fHi = s.points[i+1].Y
+			}
+			if fHi > value && fLo <= value {
+				u := solveCubic(c, value, lo, hi)
+				points = append(points, Float64Point{evalCubic(segment.x, u), value})
+			}
+		}
+	}
+	if last := s.points[len(s.points)-1]; last.Y == value {
+		points = append(points, last)
+	}
+	return
+}
+
+func TestCubicSplineIndexMatchesScan(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 20; trial++ {
+		var points []Float64Point
+		y := 0.0
+		for i := 0; i < 30; i++ {
+			// Mostly rising, with occasional folds
+			y += float64(r.Intn(9) - 2)
+			points = append(points, Float64Point{float64(r.Intn(10)), y})
+		}
+		spline, err := NewCatmullRomSpline(points, CatmullRomOptions{Alpha: 0.5})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for value := -10.0; value <= 120; value += 0.25 {
+			expected := scanSplinePointsAtY(spline, value)
+			result := spline.pointsAt(value, true)
+			AssertEqualsInt(t, len(result), len(expected), "Wrong number of points")
+			for i := range expected {
+				AssertEqualsFloat64Point(t, result[i], expected[i], "Indexed lookup differs from scan")
+			}
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package gorph
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ParametricCurve is a plane curve defined over the parameter range [0, End()].
+// Parameters outside of the range are clamped. Both FeatureLine, a sampled polyline,
+// and CubicSpline, an analytic spline, are ParametricCurves.
+type ParametricCurve interface {
+	// At evaluates the curve at the parameter t.
+	At(t float64) Float64Point
+	// Derivative evaluates the first derivative of the curve at the parameter t.
+	Derivative(t float64) Float64Point
+	// SecondDerivative evaluates the second derivative of the curve at the
+	// parameter t.
+	SecondDerivative(t float64) Float64Point
+	// End is the largest parameter of the curve.
+	End() float64
+}
+
+// End returns the largest parameter of the spline, which is its number of segments.
+func (s *CubicSpline) End() float64 {
+	return float64(len(s.segments))
+}
+
+// SecondDerivative evaluates the second derivative of the spline with respect to
+// its parameter at t, which is clamped to [0, Segments()].
+func (s *CubicSpline) SecondDerivative(t float64) Float64Point {
+	i, u := s.segmentAt(t)
+	x, y := s.segments[i].x, s.segments[i].y
+	return Float64Point{2*x[2] + 6*x[3]*u, 2*y[2] + 6*y[3]*u}
+}
+
+// segmentAt splits the parameter t, clamped to [0, End()], into a segment index and
+// the fraction along that segment.
+func (f FeatureLine) segmentAt(t float64) (int, float64) {
+	n := len(f) - 1
+	if t <= 0 {
+		return 0, 0
+	} else if t >= float64(n) {
+		return n - 1, 1
+	}
+	i := int(math.Floor(t))
+	return i, t - float64(i)
+}
+
+// At evaluates the polyline at the parameter t, where point i of the polyline lies
+// at t = i.
+func (f FeatureLine) At(t float64) Float64Point {
+	if len(f) == 0 {
+		return Float64Point{}
+	} else if len(f) == 1 {
+		return f[0]
+	}
+	i, u := f.segmentAt(t)
+	return LinearInterpolation(f[i], f[i+1], u)
+}
+
+// Derivative evaluates the first derivative of the polyline at the parameter t,
+// which is the vector of the segment holding t. At a point joining two segments, it
+// is that of the following segment.
+func (f FeatureLine) Derivative(t float64) Float64Point {
+	if len(f) < 2 {
+		return Float64Point{}
+	}
+	i, _ := f.segmentAt(t)
+	return Float64Point{f[i+1].X - f[i].X, f[i+1].Y - f[i].Y}
+}
+
+// SecondDerivative of a polyline is zero along each of its segments.
+func (f FeatureLine) SecondDerivative(t float64) Float64Point {
+	return Float64Point{}
+}
+
+// End returns the largest parameter of the polyline, which is its number of
+// segments.
+func (f FeatureLine) End() float64 {
+	if len(f) < 2 {
+		return 0
+	}
+	return float64(len(f) - 1)
+}
+
+// curveMeasureSubdivisions is the number of intervals per unit of parameter in the
+// arc length table of a CurveMeasure.
+const curveMeasureSubdivisions = 16
+
+// Nodes and weights of 5 point Gauss-Legendre quadrature on [-1, 1].
+var gaussLegendreNodes = [5]float64{0, -0.5384693101056831, 0.5384693101056831, -0.9061798459386640, 0.9061798459386640}
+var gaussLegendreWeights = [5]float64{0.5688888888888889, 0.4786286704993665, 0.4786286704993665, 0.2369268850561891, 0.2369268850561891}
+
+// CurveMeasure measures a ParametricCurve by arc length. It tabulates the arc length
+// of the curve at regular parameters, so it can answer queries at a distance s
+// along the curve, from 0 to Length(), as well as at a parameter.
+type CurveMeasure struct {
+	curve   ParametricCurve
+	params  []float64
+	lengths []float64
+}
+
+// NewCurveMeasure tabulates the arc length of a curve.
+func NewCurveMeasure(curve ParametricCurve) *CurveMeasure {
+	nIntervals := int(math.Ceil(curve.End() * curveMeasureSubdivisions))
+	m := &CurveMeasure{curve, make([]float64, nIntervals+1), make([]float64, nIntervals+1)}
+	for i := 1; i <= nIntervals; i++ {
+		m.params[i] = math.Min(float64(i)/curveMeasureSubdivisions, curve.End())
+		m.lengths[i] = m.lengths[i-1] + m.integrate(m.params[i-1], m.params[i])
+	}
+	return m
+}
+
+// Curve returns the measured curve.
+func (m *CurveMeasure) Curve() ParametricCurve {
+	return m.curve
+}
+
+// Length returns the arc length of the curve.
+func (m *CurveMeasure) Length() float64 {
+	return m.lengths[len(m.lengths)-1]
+}
+
+// integrate computes the arc length of the curve between two parameters.
+func (m *CurveMeasure) integrate(t0, t1 float64) float64 {
+	half := (t1 - t0) / 2
+	mid := (t0 + t1) / 2
+	sum := 0.0
+	for i, node := range gaussLegendreNodes {
+		sum += gaussLegendreWeights[i] * m.speed(mid+half*node)
+	}
+	return sum * half
+}
+
+func (m *CurveMeasure) speed(t float64) float64 {
+	return Distance(m.curve.Derivative(t), Float64Point{})
+}
+
+// interval finds the index of the table interval holding the parameter t.
+func (m *CurveMeasure) interval(t float64) int {
+	i := sort.SearchFloat64s(m.params, t) - 1
+	if i < 0 {
+		return 0
+	} else if i > len(m.params)-2 {
+		return len(m.params) - 2
+	}
+	return i
+}
+
+// LengthAt returns the arc length of the curve from its start to the parameter t.
+func (m *CurveMeasure) LengthAt(t float64) float64 {
+	if len(m.params) < 2 || t <= 0 {
+		return 0
+	} else if t >= m.curve.End() {
+		return m.Length()
+	}
+	i := m.interval(t)
+	return m.lengths[i] + m.integrate(m.params[i], t)
+}
+
+// ParamAt returns the parameter of the point at the arc length s along the curve,
+// clamped to the curve. This reparameterizes the curve by arc length.
+func (m *CurveMeasure) ParamAt(s float64) float64 {
+	if len(m.params) < 2 || s <= 0 {
+		return 0
+	} else if s >= m.Length() {
+		return m.curve.End()
+	}
+	i := sort.SearchFloat64s(m.lengths, s) - 1
+	if i < 0 {
+		i = 0
+	}
+	t0, t1 := m.params[i], m.params[i+1]
+	remaining := s - m.lengths[i]
+	t := t0
+	if intervalLength := m.lengths[i+1] - m.lengths[i]; intervalLength > 0 {
+		t = t0 + (t1-t0)*remaining/intervalLength
+	}
+	// Refine with Newton's method, as the derivative of arc length is the speed
+	for iteration := 0; iteration < 8; iteration++ {
+		speed := m.speed(t)
+		if speed == 0 {
+			break
+		}
+		step := (m.integrate(t0, t) - remaining) / speed
+		t = math.Max(t0, math.Min(t1, t-step))
+		if math.Abs(step) < 1e-12 {
+			break
+		}
+	}
+	return t
+}
+
+// PointAt returns the point at the arc length s along the curve.
+func (m *CurveMeasure) PointAt(s float64) Float64Point {
+	return m.curve.At(m.ParamAt(s))
+}
+
+// TangentAt returns the unit tangent of the curve at the arc length s, or the zero
+// vector where the curve has no direction.
+func (m *CurveMeasure) TangentAt(s float64) Float64Point {
+	derivative := m.curve.Derivative(m.ParamAt(s))
+	speed := Distance(derivative, Float64Point{})
+	if speed == 0 {
+		return Float64Point{}
+	}
+	return Float64Point{derivative.X / speed, derivative.Y / speed}
+}
+
+// NormalAt returns the unit normal of the curve at the arc length s, which is the
+// tangent rotated a quarter turn from the x axis towards the y axis.
+func (m *CurveMeasure) NormalAt(s float64) Float64Point {
+	tangent := m.TangentAt(s)
+	return Float64Point{-tangent.Y, tangent.X}
+}
+
+// CurvatureAt returns the signed curvature of the curve at the arc length s. It is
+// positive where the curve turns towards its normal, and zero along the straight
+// segments of a polyline or where the curve has no direction.
+func (m *CurveMeasure) CurvatureAt(s float64) float64 {
+	t := m.ParamAt(s)
+	d1 := m.curve.Derivative(t)
+	d2 := m.curve.SecondDerivative(t)
+	speed := Distance(d1, Float64Point{})
+	if speed == 0 {
+		return 0
+	}
+	return (d1.X*d2.Y - d1.Y*d2.X) / (speed * speed * speed)
+}
+
+// Resample returns n points spaced evenly by arc length along the curve, including
+// both of its ends. n must be 2 or greater.
+func (m *CurveMeasure) Resample(n int) []Float64Point {
+	if n < 2 {
+		return nil
+	}
+	pts := make([]Float64Point, n)
+	for i := range pts {
+		pts[i] = m.PointAt(m.Length() * float64(i) / float64(n-1))
+	}
+	return pts
+}
+
+// ClosestPoint finds the point of the curve closest to pt, returning its arc length
+// along the curve, the point itself and its distance from pt.
+func (m *CurveMeasure) ClosestPoint(pt Float64Point) (s float64, closest Float64Point, distance float64) {
+	best := 0
+	bestDist := math.Inf(1)
+	for i, t := range m.params {
+		if d := Distance(m.curve.At(t), pt); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	lo := m.params[MaxInt(best-1, 0)]
+	hi := m.params[best]
+	if best+1 < len(m.params) {
+		hi = m.params[best+1]
+	}
+	// Minimize the squared distance with Newton's method on its derivative
+	t := m.params[best]
+	for iteration := 0; iteration < 16; iteration++ {
+		at := m.curve.At(t)
+		d1 := m.curve.Derivative(t)
+		d2 := m.curve.SecondDerivative(t)
+		offset := Float64Point{at.X - pt.X, at.Y - pt.Y}
+		slope := offset.X*d1.X + offset.Y*d1.Y
+		curvature := d1.X*d1.X + d1.Y*d1.Y + offset.X*d2.X + offset.Y*d2.Y
+		if curvature <= 0 {
+			break
+		}
+		next := math.Max(lo, math.Min(hi, t-slope/curvature))
+		if math.Abs(next-t) < 1e-12 {
+			t = next
+			break
+		}
+		t = next
+	}
+	bestT := m.params[best]
+	for _, candidate := range []float64{t, lo, hi} {
+		if d := Distance(m.curve.At(candidate), pt); d < bestDist {
+			bestT, bestDist = candidate, d
+		}
+	}
+	return m.LengthAt(bestT), m.curve.At(bestT), bestDist
+}
+
+// CurveIntersection is a point where two curves cross, along with its arc length
+// along each of them.
+type CurveIntersection struct {
+	S1    float64
+	S2    float64
+	Point Float64Point
+}
+
+// Intersections finds the points where the measured curve crosses another, sorted by
+// their arc length along the measured curve. Each curve is first approximated by the
+// polyline through its arc length table, and every crossing of the polylines is
+// then refined on the curves themselves with Newton's method.
+func (m *CurveMeasure) Intersections(other *CurveMeasure) []CurveIntersection {
+	var intersections []CurveIntersection
+	aPts := m.tablePoints()
+	bPts := other.tablePoints()
+	for i := 1; i < len(aPts); i++ {
+		for j := 1; j < len(bPts); j++ {
+			u, v, ok := segmentIntersection(aPts[i-1], aPts[i], bPts[j-1], bPts[j])
+			// Half-open segments, so a crossing at a shared point is found once
+			if !ok || (u == 1 && i < len(aPts)-1) || (v == 1 && j < len(bPts)-1) {
+				continue
+			}
+			t1 := m.params[i-1] + u*(m.params[i]-m.params[i-1])
+			t2 := other.params[j-1] + v*(other.params[j]-other.params[j-1])
+			t1, t2 = refineIntersection(m.curve, other.curve, t1, t2)
+			intersections = append(intersections, CurveIntersection{m.LengthAt(t1), other.LengthAt(t2), m.curve.At(t1)})
+		}
+	}
+	sort.Slice(intersections, func(a, b int) bool { return intersections[a].S1 < intersections[b].S1 })
+	return intersections
+}
+
+func (m *CurveMeasure) tablePoints() []Float64Point {
+	pts := make([]Float64Point, len(m.params))
+	for i, t := range m.params {
+		pts[i] = m.curve.At(t)
+	}
+	return pts
+}
+
+// segmentIntersection finds the fractions u along segment p1-p2 and v along segment
+// q1-q2 at which the segments cross. Parallel segments do not cross.
+func segmentIntersection(p1, p2, q1, q2 Float64Point) (u, v float64, ok bool) {
+	r := Float64Point{p2.X - p1.X, p2.Y - p1.Y}
+	s := Float64Point{q2.X - q1.X, q2.Y - q1.Y}
+	denominator := r.X*s.Y - r.Y*s.X
+	if denominator == 0 {
+		return 0, 0, false
+	}
+	w := Float64Point{q1.X - p1.X, q1.Y - p1.Y}
+	u = (w.X*s.Y - w.Y*s.X) / denominator
+	v = (w.X*r.Y - w.Y*r.X) / denominator
+	return u, v, u >= 0 && u <= 1 && v >= 0 && v <= 1
+}
+
+// refineIntersection solves a(t1) = b(t2) with Newton's method from an initial
+// guess, keeping the guess if the iteration fails to improve on it.
+func refineIntersection(a, b ParametricCurve, t1, t2 float64) (float64, float64) {
+	gap := func(t1, t2 float64) float64 { return Distance(a.At(t1), b.At(t2)) }
+	best1, best2, bestGap := t1, t2, gap(t1, t2)
+	for iteration := 0; iteration < 16 && bestGap > 1e-12; iteration++ {
+		pa, pb := a.At(t1), b.At(t2)
+		da, db := a.Derivative(t1), b.Derivative(t2)
+		fx, fy := pa.X-pb.X, pa.Y-pb.Y
+		// Solve [da -db] [d1 d2]^T = -f
+		determinant := -da.X*db.Y + db.X*da.Y
+		if determinant == 0 {
+			break
+		}
+		d1 := (-fx*-db.Y - -db.X*-fy) / determinant
+		d2 := (da.X*-fy - da.Y*-fx) / determinant
+		t1 = math.Max(0, math.Min(a.End(), t1+d1))
+		t2 = math.Max(0, math.Min(b.End(), t2+d2))
+		if g := gap(t1, t2); g < bestGap {
+			best1, best2, bestGap = t1, t2, g
+		}
+	}
+	return best1, best2
+}
+
+// adaptiveMaxDepth bounds the number of times AdaptiveSample halves an interval.
+const adaptiveMaxDepth = 16
+
+// AdaptiveSample samples a curve with as few points as it takes for the polyline
+// through them to stay within tolerance of the curve. Every unit interval of the
+// parameter, such as a segment of a CubicSpline, is halved until the curve at a
+// quarter, half and three quarters of the interval lies within tolerance of the
+// chord across it, so tight bends receive more points than straight runs. The first
+// and last points of the curve, and those at every whole parameter, are included.
+// The tolerance must be positive.
+func AdaptiveSample(curve ParametricCurve, tolerance float64) ([]Float64Point, error) {
+	if !(tolerance > 0) {
+		return nil, errors.New("AdaptiveSample: Tolerance must be positive")
+	}
+	end := curve.End()
+	pts := []Float64Point{curve.At(0)}
+	for t0 := 0.0; t0 < end; t0++ {
+		t1 := math.Min(t0+1, end)
+		pts = adaptiveSubdivide(curve, t0, t1, curve.At(t0), curve.At(t1), tolerance, 0, pts)
+	}
+	return pts, nil
+}
+
+// adaptiveSubdivide appends the points of the curve after p0 up to and including
+// p1, the points at t0 and t1, halving the interval until it is flat.
+func adaptiveSubdivide(curve ParametricCurve, t0, t1 float64, p0, p1 Float64Point, tolerance float64, depth int, pts []Float64Point) []Float64Point {
+	mid := (t0 + t1) / 2
+	pMid := curve.At(mid)
+	flat := depth >= adaptiveMaxDepth
+	if !flat {
+		flat = chordDistance(pMid, p0, p1) <= tolerance &&
+			chordDistance(curve.At((t0+mid)/2), p0, p1) <= tolerance &&
+			chordDistance(curve.At((mid+t1)/2), p0, p1) <= tolerance
+	}
+	if flat {
+		return append(pts, p1)
+	}
+	pts = adaptiveSubdivide(curve, t0, mid, p0, pMid, tolerance, depth+1, pts)
+	return adaptiveSubdivide(curve, mid, t1, pMid, p1, tolerance, depth+1, pts)
+}
+
+// chordDistance is the distance from pt to the segment from p0 to p1.
+func chordDistance(pt, p0, p1 Float64Point) float64 {
+	chord := Float64Point{p1.X - p0.X, p1.Y - p0.Y}
+	lengthSq := chord.X*chord.X + chord.Y*chord.Y
+	if lengthSq == 0 {
+		return Distance(pt, p0)
+	}
+	u := ((pt.X-p0.X)*chord.X + (pt.Y-p0.Y)*chord.Y) / lengthSq
+	u = math.Max(0, math.Min(1, u))
+	return Distance(pt, Float64Point{p0.X + u*chord.X, p0.Y + u*chord.Y})
+}
@@ -0,0 +1,155 @@
+package gorph
+
+import (
+	"math"
+	"testing"
+)
+
+func circleSpline(t *testing.T, radius float64, nPoints int) *CubicSpline {
+	points := make([]Float64Point, nPoints)
+	for i := range points {
+		angle := 2 * math.Pi * float64(i) / float64(nPoints)
+		points[i] = Float64Point{radius * math.Cos(angle), radius * math.Sin(angle)}
+	}
+	spline, err := NewCatmullRomSpline(points, CatmullRomOptions{Alpha: 0.5, Closed: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return spline
+}
+
+func TestCurveMeasurePolyline(t *testing.T) {
+	m := NewCurveMeasure(FeatureLine{{0, 0}, {3, 0}, {3, 4}})
+	AssertEqualsFloat64Slice(t, []float64{m.Length()}, []float64{7}, "Incorrect length")
+	AssertEqualsFloat64PointTolerance(t, m.PointAt(5), Float64Point{3, 2}, .000001, "Incorrect point at distance")
+	AssertEqualsFloat64PointTolerance(t, m.TangentAt(1), Float64Point{1, 0}, .000001, "Incorrect tangent")
+	AssertEqualsFloat64PointTolerance(t, m.TangentAt(5), Float64Point{0, 1}, .000001, "Incorrect tangent")
+	AssertEqualsFloat64PointTolerance(t, m.NormalAt(1), Float64Point{0, 1}, .000001, "Incorrect normal")
+	AssertEqualsFloat64Slice(t, []float64{m.CurvatureAt(2)}, []float64{0}, "Polyline segment should be straight")
+	AssertEqualsFloat64PointTolerance(t, Float64Point{m.ParamAt(5), m.LengthAt(1.5)}, Float64Point{1.5, 5}, .000001, "Incorrect reparameterization")
+	pts := m.Resample(8)
+	AssertEqualsInt(t, len(pts), 8, "Incorrect number of resampled points")
+	AssertEqualsFloat64PointTolerance(t, pts[4], Float64Point{3, 1}, .000001, "Incorrect resampled point")
+}
+
+func TestCurveMeasureSpline(t *testing.T) {
+	radius := 10.0
+	m := NewCurveMeasure(circleSpline(t, radius, 24))
+	if math.Abs(m.Length()-2*math.Pi*radius)/(2*math.Pi*radius) > 0.001 {
+		t.Error("Incorrect length of circle", m.Length())
+	}
+	for s := 0.0; s < m.Length(); s += m.Length() / 7 {
+		AssertEqualsFloat64PointTolerance(t, Float64Point{m.LengthAt(m.ParamAt(s)), 0}, Float64Point{s, 0}, .000001, "Reparameterization does not round trip")
+		if math.Abs(m.CurvatureAt(s)-1/radius) > 0.01 {
+			t.Error("Incorrect curvature of circle", s, m.CurvatureAt(s))
+		}
+		pt := m.PointAt(s)
+		// The normal of a counterclockwise circle points to its center
+		normal := m.NormalAt(s)
+		AssertEqualsFloat64PointTolerance(t, normal, Float64Point{-pt.X / radius, -pt.Y / radius}, .01, "Incorrect normal")
+	}
+}
+
+func TestCurveMeasureClosestPoint(t *testing.T) {
+	m := NewCurveMeasure(FeatureLine{{0, 0}, {3, 0}, {3, 4}})
+	s, pt, distance := m.ClosestPoint(Float64Point{1, 1})
+	AssertEqualsFloat64PointTolerance(t, pt, Float64Point{1, 0}, .000001, "Incorrect closest point")
+	AssertEqualsFloat64PointTolerance(t, Float64Point{s, distance}, Float64Point{1, 1}, .000001, "Incorrect distance")
+	s, pt, _ = m.ClosestPoint(Float64Point{5, 3})
+	AssertEqualsFloat64PointTolerance(t, pt, Float64Point{3, 3}, .000001, "Incorrect closest point on second segment")
+	AssertEqualsFloat64Slice(t, []float64{s}, []float64{6}, "Incorrect arc length of closest point")
+
+	circle := NewCurveMeasure(circleSpline(t, 10, 24))
+	_, pt, distance = circle.ClosestPoint(Float64Point{0, 20})
+	AssertEqualsFloat64PointTolerance(t, pt, Float64Point{0, 10}, .000001, "Incorrect closest point on circle")
+	AssertEqualsFloat64PointTolerance(t, Float64Point{distance, 0}, Float64Point{10, 0}, .000001, "Incorrect distance to circle")
+}
+
+func TestCurveMeasureIntersections(t *testing.T) {
+	a := NewCurveMeasure(FeatureLine{{0, 0}, {2, 2}})
+	b := NewCurveMeasure(FeatureLine{{0, 2}, {2, 0}})
+	intersections := a.Intersections(b)
+	AssertEqualsInt(t, len(intersections), 1, "Incorrect number of intersections")
+	AssertEqualsFloat64PointTolerance(t, intersections[0].Point, Float64Point{1, 1}, .000001, "Incorrect intersection")
+	AssertEqualsFloat64PointTolerance(t, Float64Point{intersections[0].S1, intersections[0].S2}, Float64Point{math.Sqrt2, math.Sqrt2}, .000001, "Incorrect arc lengths")
+
+	// A crossing at a shared vertex is found once
+	vertex := NewCurveMeasure(FeatureLine{{0, 0}, {1, 1}, {2, 0}})
+	vertical := NewCurveMeasure(FeatureLine{{1, 0}, {1, 2}})
+	AssertEqualsInt(t, len(vertex.Intersections(vertical)), 1, "Vertex crossing not found once")
+
+	// A line through the center of a circle crosses it twice
+	circle := NewCurveMeasure(circleSpline(t, 10, 24))
+	line := NewCurveMeasure(FeatureLine{{-20, 1}, {20, 1}})
+	intersections = line.Intersections(circle)
+	AssertEqualsInt(t, len(intersections), 2, "Incorrect number of circle intersections")
+	for _, intersection := range intersections {
+		AssertEqualsFloat64PointTolerance(t, Float64Point{math.Abs(intersection.Point.X), intersection.Point.Y}, Float64Point{math.Sqrt(99), 1}, .01, "Incorrect circle intersection")
+		AssertEqualsFloat64PointTolerance(t, circle.PointAt(intersection.S2), intersection.Point, .000001, "Intersection not on circle")
+	}
+}
+
+func TestAdaptiveSampleStraight(t *testing.T) {
+	pts, err := AdaptiveSample(FeatureLine{{0, 0}, {1, 1}, {2, 2}}, 0.01)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(pts), 3, "Straight runs should not be subdivided")
+	_, err = AdaptiveSample(FeatureLine{{0, 0}, {1, 1}}, 0)
+	if err == nil {
+		t.Error("Expected error for zero tolerance")
+	}
+}
+
+func TestAdaptiveSampleTolerance(t *testing.T) {
+	circle := circleSpline(t, 100, 8)
+	tolerance := 0.05
+	pts, err := AdaptiveSample(circle, tolerance)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	m := NewCurveMeasure(circle)
+	// Every point of the curve lies within tolerance of the polyline
+	polyline := NewCurveMeasure(FeatureLine(pts))
+	for s := 0.0; s < m.Length(); s += m.Length() / 500 {
+		if _, _, distance := polyline.ClosestPoint(m.PointAt(s)); distance > tolerance*1.01 {
+			t.Fatal("Curve strays from adaptive polyline by", distance)
+		}
+	}
+	LogVerbose(t, "Adaptive points:", len(pts))
+}
+
+func TestAdaptiveCubicCatmullRomInterpolation(t *testing.T) {
+	// A long straight run followed by a tight bend
+	points := []Float64Point{{0, 0}, {100, 0}, {101, 1}, {100, 2}}
+	pts, err := AdaptiveCubicCatmullRomInterpolation(points, CatmullRomOptions{Alpha: 0.5}, 0.01)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	onBend := func(pts []Float64Point) int {
+		n := 0
+		for _, pt := range pts {
+			if pt.X >= 99 {
+				n++
+			}
+		}
+		return n
+	}
+	// Spacing the same number of steps by chord length leaves the bend sparse
+	fixed, err := CubicCatmullRomInterpolation(points, 0.5, len(pts))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if onBend(pts) <= 2*onBend(fixed) {
+		t.Error("Bend should receive more points than with fixed steps", onBend(pts), onBend(fixed))
+	}
+	for _, pt := range points {
+		found := false
+		for _, sampled := range pts {
+			found = found || sampled == pt
+		}
+		if !found {
+			t.Error("Control point missing from samples", pt)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package gorph
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// DisplacementField is a dense warp storing a displacement (dx, dy) for every pixel
+// of its bounds, in the manner of an image.RGBA64. Warping by the field fills the
+// pixel at (x, y) with the color sampled from the original image at the pixel's
+// center moved by its displacement, (x + 0.5 + dx, y + 0.5 + dy). A NaN displacement
+// marks a pixel with no source, which Warp leaves transparent.
+//
+// A field is a common representation of whichever warp produced it, such as a mesh
+// warp, an affine transform or a homography, so it can be cached, composed with
+// other fields and visualized.
+type DisplacementField struct {
+	// Pix holds the displacements in row major order, dx then dy for every pixel.
+	// The displacement of the pixel at (x, y) starts at
+	// Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*2].
+	Pix []float32
+	// Stride is the Pix stride between vertically adjacent pixels.
+	Stride int
+	// Rect is the bounds of the field.
+	Rect image.Rectangle
+}
+
+// NewDisplacementField returns a field of the given bounds that displaces nothing.
+func NewDisplacementField(r image.Rectangle) *DisplacementField {
+	return &DisplacementField{make([]float32, 2*r.Dx()*r.Dy()), 2 * r.Dx(), r}
+}
+
+// Bounds returns the bounds of the field.
+func (f *DisplacementField) Bounds() image.Rectangle {
+	return f.Rect
+}
+
+// PixOffset returns the index of the first element of Pix for the pixel at (x, y).
+func (f *DisplacementField) PixOffset(x, y int) int {
+	return (y-f.Rect.Min.Y)*f.Stride + (x-f.Rect.Min.X)*2
+}
+
+// At returns the displacement of the pixel at (x, y), or zero outside of the bounds.
+func (f *DisplacementField) At(x, y int) Float64Point {
+	if !(image.Point{x, y}.In(f.Rect)) {
+		return Float64Point{0, 0}
+	}
+	i := f.PixOffset(x, y)
+	return Float64Point{float64(f.Pix[i]), float64(f.Pix[i+1])}
+}
+
+// Set sets the displacement of the pixel at (x, y). It does nothing outside of the
+// bounds.
+func (f *DisplacementField) Set(x, y int, d Float64Point) {
+	if !(image.Point{x, y}.In(f.Rect)) {
+		return
+	}
+	i := f.PixOffset(x, y)
+	f.Pix[i] = float32(d.X)
+	f.Pix[i+1] = float32(d.Y)
+}
+
+// Source returns the location of the original image sampled for the pixel at (x, y).
+func (f *DisplacementField) Source(x, y int) Float64Point {
+	return Float64Point{float64(x) + 0.5, float64(y) + 0.5}.Add(f.At(x, y))
+}
+
+// displacementAt interpolates the displacement bilinearly between the centers of the
+// four pixels nearest a location, clamping to the edges of the field.
+func (f *DisplacementField) displacementAt(pt Float64Point) Float64Point {
+	px := pt.X - 0.5
+	py := pt.Y - 0.5
+	x0 := math.Floor(px)
+	y0 := math.Floor(py)
+	fx := px - x0
+	fy := py - y0
+	at := func(x, y int) Float64Point {
+		return f.At(clampInt(x, f.Rect.Min.X, f.Rect.Max.X-1), clampInt(y, f.Rect.Min.Y, f.Rect.Max.Y-1))
+	}
+	top := at(int(x0), int(y0)).Lerp(at(int(x0)+1, int(y0)), fx)
+	bottom := at(int(x0), int(y0)+1).Lerp(at(int(x0)+1, int(y0)+1), fx)
+	return top.Lerp(bottom, fy)
+}
+
+// Compose returns the field of warping by f and then by after. Its bounds are those
+// of after, and every displacement of f is interpolated bilinearly at the location
+// after samples.
+func (f *DisplacementField) Compose(after *DisplacementField) *DisplacementField {
+	result := NewDisplacementField(after.Rect)
+	for y := after.Rect.Min.Y; y < after.Rect.Max.Y; y++ {
+		for x := after.Rect.Min.X; x < after.Rect.Max.X; x++ {
+			d := after.At(x, y)
+			result.Set(x, y, d.Add(f.displacementAt(after.Source(x, y))))
+		}
+	}
+	return result
+}
+
+// Image visualizes the field as an opaque image, with dx in the red channel and dy in
+// the green channel. A displacement of zero is half intensity, and one of scale or
+// -scale is full or zero intensity, beyond which the channel is clamped. Pixels with
+// no source are transparent. The image is read back by DisplacementFieldFromRG.
+func (f *DisplacementField) Image(scale float64) *image.RGBA64 {
+	img := image.NewRGBA64(f.Rect)
+	channel := func(value float64) uint16 {
+		return uint16(math.Round(clampUnit(0.5+value/(2*scale)) * 0xffff))
+	}
+	for y := f.Rect.Min.Y; y < f.Rect.Max.Y; y++ {
+		for x := f.Rect.Min.X; x < f.Rect.Max.X; x++ {
+			d := f.At(x, y)
+			if math.IsNaN(d.X) || math.IsNaN(d.Y) {
+				continue
+			}
+			img.SetRGBA64(x, y, color.RGBA64{channel(d.X), channel(d.Y), 0, 0xffff})
+		}
+	}
+	return img
+}
+
+// Warp resamples an image by a displacement field, with the sampler and border mode
+// used for every pixel. The result has the bounds of the field. The sampler defaults
+// to BilinearSampler if nil. Returns an error if the field is empty.
+func Warp(img image.Image, field *DisplacementField, sampler Sampler, border BorderMode) (*image.RGBA64, error) {
+	if field == nil || field.Rect.Empty() {
+		return nil, errors.New("Warp: Displacement field must not be empty")
+	}
+	if sampler == nil {
+		sampler = BilinearSampler
+	}
+	result := image.NewRGBA64(field.Rect)
+	for y := field.Rect.Min.Y; y < field.Rect.Max.Y; y++ {
+		for x := field.Rect.Min.X; x < field.Rect.Max.X; x++ {
+			srcPt := field.Source(x, y)
+			if math.IsNaN(srcPt.X) || math.IsNaN(srcPt.Y) || math.IsInf(srcPt.X, 0) || math.IsInf(srcPt.Y, 0) {
+				continue
+			}
+			result.Set(x, y, sampler(img, srcPt, border))
+		}
+	}
+	return result, nil
+}
+
+// DisplacementFieldFromFunc builds the field of any warp, such as the Apply method of
+// a ThinPlateSpline, given the function returning the location of the original image
+// sampled for a location of the result. It is called with the center of every pixel
+// of bounds.
+func DisplacementFieldFromFunc(bounds image.Rectangle, source func(Float64Point) Float64Point) *DisplacementField {
+	field := NewDisplacementField(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			center := Float64Point{float64(x) + 0.5, float64(y) + 0.5}
+			field.Set(x, y, source(center).Sub(center))
+		}
+	}
+	return field
+}
+
+// AffineDisplacementField builds the field of Transform by an affine transform, over
+// the given bounds. Returns an error if the transform is singular.
+func AffineDisplacementField(a Affine, bounds image.Rectangle) (*DisplacementField, error) {
+	inverse, err := a.Invert()
+	if err != nil {
+		return nil, errors.New("AffineDisplacementField: " + err.Error())
+	}
+	return DisplacementFieldFromFunc(bounds, inverse.Apply), nil
+}
+
+// HomographyDisplacementField builds the field of Perspective by a homography, over
+// the given bounds. Pixels on the horizon, or on the far side of it from the center
+// of the bounds, have no source. Returns an error if the homography is singular.
+func HomographyDisplacementField(h Homography, bounds image.Rectangle) (*DisplacementField, error) {
+	inverse, err := h.Invert()
+	if err != nil {
+		return nil, errors.New("HomographyDisplacementField: " + err.Error())
+	}
+	_, wCenter := inverse.project(ToFloat64Rect(bounds).Center())
+	return DisplacementFieldFromFunc(bounds, func(pt Float64Point) Float64Point {
+		srcPt, w := inverse.project(pt)
+		if w == 0 || math.Signbit(w) != math.Signbit(wCenter) {
+			return Float64Point{math.NaN(), math.NaN()}
+		}
+		return srcPt
+	}), nil
+}
+
+// ThinPlateSplineDisplacementField builds the field that warps an image so the start
+// point of every pair moves onto its destination point, over the given bounds. The
+// field follows the ThinPlateSpline fitted from the destination points back to the
+// start points with the given regularization. Returns an error if the spline cannot
+// be fitted.
+func ThinPlateSplineDisplacementField(pairs []LandmarkPair, regularization float64, bounds image.Rectangle) (*DisplacementField, error) {
+	inverse := make([]LandmarkPair, len(pairs))
+	for i, pair := range pairs {
+		inverse[i] = LandmarkPair{pair.Dest, pair.Start}
+	}
+	tps, err := NewThinPlateSpline(inverse, regularization)
+	if err != nil {
+		return nil, errors.New("ThinPlateSplineDisplacementField: " + err.Error())
+	}
+	return DisplacementFieldFromFunc(bounds, tps.Apply), nil
+}
+
+// DisplacementFieldFromRG reads a field from the red and green channels of an image,
+// as written by DisplacementField.Image. Half intensity is no displacement, and full
+// or zero intensity is a displacement of scale or -scale. Transparent pixels have no
+// source.
+func DisplacementFieldFromRG(img image.Image, scale float64) *DisplacementField {
+	bounds := img.Bounds()
+	field := NewDisplacementField(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, _, a := img.At(x, y).RGBA()
+			if a == 0 {
+				field.Set(x, y, Float64Point{math.NaN(), math.NaN()})
+				continue
+			}
+			// Undo the premultiplication by alpha
+			field.Set(x, y, Float64Point{scale * (2*float64(r)/float64(a) - 1), scale * (2*float64(g)/float64(a) - 1)})
+		}
+	}
+	return field
+}
+
+// DisplacementFieldFromGray reads a field from the brightness of an image, which
+// displaces every pixel along the given direction. Half intensity is no
+// displacement, and full or zero intensity is a displacement of direction or
+// -direction.
+func DisplacementFieldFromGray(img image.Image, direction Float64Point) *DisplacementField {
+	bounds := img.Bounds()
+	field := NewDisplacementField(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray := color.Gray16Model.Convert(img.At(x, y)).(color.Gray16)
+			field.Set(x, y, direction.Mul(2*float64(gray.Y)/0xffff-1))
+		}
+	}
+	return field
+}
+
+// DisplacementFields builds the fields of the mesh warps of MorphFrame at the time
+// fraction t, over the given bounds. The start field warps the start image onto the
+// grid interpolated at t with timeInterp, and the dest field warps the destination
+// image onto it. A field follows the continuous mapping of the two passes of the
+// warp, and pixels outside of the outermost grid lines have no source. If the grid
+// has reference bounds, it is first rescaled to the given bounds. Returns an error
+// if any grid line folds back on itself.
+func (m *MorphGrid) DisplacementFields(t float64, timeInterp InterpolationFunc, bounds image.Rectangle) (start, dest *DisplacementField, err error) {
+	if bounds.Empty() {
+		return nil, nil, errors.New("DisplacementFields: Bounds must not be empty")
+	}
+	resolvedGrid, err := m.resolvedFor(bounds)
+	if err != nil {
+		return nil, nil, err
+	}
+	intermedGrid := resolvedGrid.interpolatedGrid(timeInterp, t)
+	start, err = meshDisplacementField(resolvedGrid.start, intermedGrid, resolvedGrid.style, bounds)
+	if err != nil {
+		return nil, nil, err
+	}
+	dest, err = meshDisplacementField(resolvedGrid.dest, intermedGrid, resolvedGrid.style, bounds)
+	if err != nil {
+		return nil, nil, err
+	}
+	return start, dest, nil
+}
+
+// meshDisplacementField builds the field of warpToGrid. For the center of every
+// pixel, the vertical pass is undone between the horizontal lines of the target and
+// auxilary grids, and then the horizontal pass between the vertical lines of the
+// auxilary and source grids. The vertical lines are tabulated at the center of every
+// row, and interpolated linearly between rows.
+func meshDisplacementField(sourceGrid, targetGrid *float64CoordinateGrid, style lineStyle, bounds image.Rectangle) (*DisplacementField, error) {
+	auxGrid, err := auxilaryGrid(sourceGrid, targetGrid)
+	if err != nil {
+		return nil, err
+	}
+	sourceVertical, err := sourceGrid.allCurves(true, style)
+	if err != nil {
+		return nil, err
+	}
+	auxVertical, err := auxGrid.allCurves(true, style)
+	if err != nil {
+		return nil, err
+	}
+	auxHorizontal, err := auxGrid.allCurves(false, style)
+	if err != nil {
+		return nil, err
+	}
+	targetHorizontal, err := targetGrid.allCurves(false, style)
+	if err != nil {
+		return nil, err
+	}
+	if len(sourceVertical) != len(auxVertical) || len(auxHorizontal) != len(targetHorizontal) {
+		return nil, errors.New("meshDisplacementField: Grids do not have the same number of splines")
+	}
+
+	// Crossings of every curve at the center of every row or column, NaN where the
+	// curve does not reach
+	crossings := func(curves []axisCurve, atY bool, value float64) ([]float64, error) {
+		values := make([]float64, len(curves))
+		for i, curve := range curves {
+			var pts []Float64Point
+			var err error
+			if atY {
+				pts, err = curve.InterpolatePointsAtY(value)
+			} else {
+				pts, err = curve.InterpolatePointsAtX(value)
+			}
+			if err != nil {
+				values[i] = math.NaN()
+				continue
+			}
+			if len(pts) > 1 {
+				return nil, errors.New("meshDisplacementField: Spline " + strconv.Itoa(i) + " folds back on itself at " + strconv.FormatFloat(value, 'g', 8, 64) + "; MorphGrid.Validate can locate the offending grid line")
+			}
+			values[i] = pts[0].X
+			if !atY {
+				values[i] = pts[0].Y
+			}
+		}
+		return values, nil
+	}
+	nRows := bounds.Dy()
+	sourceRows := make([][]float64, nRows)
+	auxRows := make([][]float64, nRows)
+	for row := 0; row < nRows; row++ {
+		y := float64(bounds.Min.Y+row) + 0.5
+		if sourceRows[row], err = crossings(sourceVertical, true, y); err != nil {
+			return nil, err
+		}
+		if auxRows[row], err = crossings(auxVertical, true, y); err != nil {
+			return nil, err
+		}
+	}
+	rowAt := func(rows [][]float64, y float64) []float64 {
+		position := math.Max(0, math.Min(float64(nRows-1), y-0.5-float64(bounds.Min.Y)))
+		row := int(math.Floor(position))
+		if row == nRows-1 {
+			return rows[row]
+		}
+		fraction := position - float64(row)
+		values := make([]float64, len(rows[row]))
+		for i := range values {
+			values[i] = rows[row][i] + fraction*(rows[row+1][i]-rows[row][i])
+		}
+		return values
+	}
+
+	field := NewDisplacementField(bounds)
+	noSource := Float64Point{math.NaN(), math.NaN()}
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		cx := float64(x) + 0.5
+		targetYs, err := crossings(targetHorizontal, false, cx)
+		if err != nil {
+			return nil, err
+		}
+		auxYs, err := crossings(auxHorizontal, false, cx)
+		if err != nil {
+			return nil, err
+		}
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			cy := float64(y) + 0.5
+			auxY, ok := piecewiseLinear(targetYs, auxYs, cy)
+			if !ok {
+				field.Set(x, y, noSource)
+				continue
+			}
+			sourceX, ok := piecewiseLinear(rowAt(auxRows, auxY), rowAt(sourceRows, auxY), cx)
+			if !ok {
+				field.Set(x, y, noSource)
+				continue
+			}
+			field.Set(x, y, Float64Point{sourceX - cx, auxY - cy})
+		}
+	}
+	return field, nil
+}
+
+// piecewiseLinear maps value from the span between consecutive from values that
+// holds it onto the matching span of to values. Returns false if no span holds it.
+func piecewiseLinear(from, to []float64, value float64) (float64, bool) {
+	for i := 0; i+1 < len(from); i++ {
+		if from[i] <= value && value < from[i+1] {
+			fraction := (value - from[i]) / (from[i+1] - from[i])
+			result := to[i] + fraction*(to[i+1]-to[i])
+			return result, !math.IsNaN(result)
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,130 @@
+package gorph
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestDisplacementFieldAffineWarp(t *testing.T) {
+	img := gradientImage(6, 4)
+	field, err := AffineDisplacementField(TranslateAffine(2, 1), img.Bounds())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64Point(t, field.At(3, 2), Float64Point{-2, -1}, "Displacement incorrect")
+	result, err := Warp(img, field, NearestNeighborSampler, BorderTransparent)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected, err := Transform(img, TranslateAffine(2, 1), TransformOptions{Sampler: NearestNeighborSampler})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for x := 0; x < 6; x++ {
+		for y := 0; y < 4; y++ {
+			AssertEqualsImageColor(t, result.At(x, y), expected.At(x, y), "Warp differs from Transform")
+		}
+	}
+	_, err = Warp(img, NewDisplacementField(image.Rectangle{}), nil, BorderTransparent)
+	if err == nil {
+		t.Error("Expected error for an empty field")
+	}
+}
+
+func TestDisplacementFieldCompose(t *testing.T) {
+	bounds := image.Rect(0, 0, 8, 8)
+	first, _ := AffineDisplacementField(TranslateAffine(1, 0), bounds)
+	second, _ := AffineDisplacementField(ScaleAffine(2, 2), bounds)
+	composed := first.Compose(second)
+	expected, _ := AffineDisplacementField(TranslateAffine(1, 0).Then(ScaleAffine(2, 2)), bounds)
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			AssertEqualsFloat64PointTolerance(t, composed.At(x, y), expected.At(x, y), 1e-6, "Composed field incorrect")
+		}
+	}
+}
+
+func TestDisplacementFieldImageRoundTrip(t *testing.T) {
+	field := NewDisplacementField(image.Rect(0, 0, 3, 2))
+	field.Set(0, 0, Float64Point{1.5, -3})
+	field.Set(2, 1, Float64Point{math.NaN(), math.NaN()})
+	result := DisplacementFieldFromRG(field.Image(4), 4)
+	AssertEqualsFloat64PointTolerance(t, result.At(0, 0), Float64Point{1.5, -3}, 1e-3, "Displacement did not round trip")
+	AssertEqualsFloat64PointTolerance(t, result.At(1, 0), Float64Point{0, 0}, 1e-3, "Zero displacement did not round trip")
+	if d := result.At(2, 1); !math.IsNaN(d.X) {
+		t.Error("Pixel without a source did not round trip", d)
+	}
+}
+
+func TestDisplacementFieldFromGray(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.Gray{0xff})
+	img.Set(1, 0, color.Gray{0})
+	field := DisplacementFieldFromGray(img, Float64Point{3, 1})
+	AssertEqualsFloat64PointTolerance(t, field.At(0, 0), Float64Point{3, 1}, 1e-6, "White displacement incorrect")
+	AssertEqualsFloat64PointTolerance(t, field.At(1, 0), Float64Point{-3, -1}, 1e-6, "Black displacement incorrect")
+}
+
+func TestMorphGridDisplacementFields(t *testing.T) {
+	bounds := image.Rect(0, 0, 64, 64)
+	mGrid := squareMorphGrid(64, 64, image.Point{40, 40})
+	start, dest, err := mGrid.DisplacementFields(1, LinearInterpolationImagePoints, bounds)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, start.Source(0, 0), Float64Point{0.5, 0.5}, 0.5, "Fixed corner moved")
+	for _, pt := range []image.Point{{10, 10}, {40, 40}, {63, 5}} {
+		AssertEqualsFloat64PointTolerance(t, dest.At(pt.X, pt.Y), Float64Point{0, 0}, 1e-3, "Destination image is displaced onto its own grid")
+	}
+	// Warping a gradient whose colors encode their location shows where the mesh warp
+	// samples every pixel from, which the field follows to within a pixel.
+	img := image.NewRGBA64(bounds)
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			img.Set(x, y, color.RGBA64{uint16(x * 0x400), uint16(y * 0x400), 0, 0xffff})
+		}
+	}
+	expected, err := warpToGrid(img, mGrid.start, mGrid.dest, mGrid.style)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, pt := range []image.Point{{40, 38}, {40, 43}, {20, 45}, {50, 20}, {10, 60}} {
+		r, g, _, _ := expected.At(pt.X, pt.Y).RGBA()
+		sampled := Float64Point{float64(r)/0x400 + 0.5, float64(g)/0x400 + 0.5}
+		AssertEqualsFloat64PointTolerance(t, start.Source(pt.X, pt.Y), sampled, 1, "Field differs from the mesh warp")
+	}
+}
+
+func TestHomographyDisplacementField(t *testing.T) {
+	bounds := image.Rect(0, 0, 8, 8)
+	a := RotateAffine(0.2).Then(TranslateAffine(1, -2))
+	expected, _ := AffineDisplacementField(a, bounds)
+	field, err := HomographyDisplacementField(HomographyFromAffine(a), bounds)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			AssertEqualsFloat64PointTolerance(t, field.At(x, y), expected.At(x, y), 1e-5, "Homography field differs from the affine field")
+		}
+	}
+}
+
+func TestThinPlateSplineDisplacementField(t *testing.T) {
+	pairs, _ := PairLandmarks(
+		[]Float64Point{{4.5, 4.5}, {27.5, 4.5}, {27.5, 27.5}, {4.5, 27.5}, {16.5, 16.5}},
+		[]Float64Point{{4.5, 4.5}, {27.5, 4.5}, {27.5, 27.5}, {4.5, 27.5}, {20.5, 12.5}},
+	)
+	field, err := ThinPlateSplineDisplacementField(pairs, 0, image.Rect(0, 0, 32, 32))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, field.Source(20, 12), Float64Point{16.5, 16.5}, 1e-3, "Moved landmark sampled from the wrong location")
+	AssertEqualsFloat64PointTolerance(t, field.Source(4, 27), Float64Point{4.5, 27.5}, 1e-3, "Pinned landmark sampled from the wrong location")
+	_, err = ThinPlateSplineDisplacementField(pairs[:2], 0, image.Rect(0, 0, 32, 32))
+	if err == nil {
+		t.Error("Expected error for two landmark pairs")
+	}
+}
@@ -0,0 +1,66 @@
+package gorph
+
+import (
+	"errors"
+	"image"
+	"sort"
+)
+
+// EaseLinear leaves the time fraction unchanged.
+func EaseLinear(t float64) float64 {
+	return t
+}
+
+// EaseIn starts slowly and accelerates, following a quadratic curve.
+func EaseIn(t float64) float64 {
+	return t * t
+}
+
+// EaseOut starts quickly and decelerates, following a quadratic curve.
+func EaseOut(t float64) float64 {
+	return 1 - (1-t)*(1-t)
+}
+
+// EaseInOut starts and ends slowly, following the smoothstep curve.
+func EaseInOut(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+var easings = map[string]func(float64) float64{
+	"linear":      EaseLinear,
+	"ease-in":     EaseIn,
+	"ease-out":    EaseOut,
+	"ease-in-out": EaseInOut,
+}
+
+// EasingByName looks up an easing function by its name, for use as the
+// nominalTimeConversion of Morph or to ease the geometry of a morph. The names are
+// "linear", "ease-in", "ease-out" and "ease-in-out"; an empty name is linear.
+func EasingByName(name string) (func(float64) float64, error) {
+	if name == "" {
+		return EaseLinear, nil
+	}
+	easing, ok := easings[name]
+	if !ok {
+		return nil, errors.New("EasingByName: Unknown easing \"" + name + "\"")
+	}
+	return easing, nil
+}
+
+// EasingNames lists the names accepted by EasingByName, sorted.
+func EasingNames() []string {
+	names := make([]string, 0, len(easings))
+	for name := range easings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EasedLinearInterpolation creates an InterpolationFunc which linearly interpolates
+// between two points after the time fraction is passed through the easing function.
+func EasedLinearInterpolation(easing func(float64) float64) InterpolationFunc {
+	return func(start, end image.Point, fractionFromStart float64) Float64Point {
+		return LinearInterpolationImagePoints(start, end, easing(fractionFromStart))
+	}
+}
@@ -0,0 +1,25 @@
+package gorph
+
+import (
+	"image"
+	"testing"
+)
+
+func TestEasingByName(t *testing.T) {
+	for _, name := range EasingNames() {
+		easing, err := EasingByName(name)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		AssertEqualsFloat64PointTolerance(t, Float64Point{easing(0), easing(1)}, Float64Point{0, 1}, .000001, name)
+	}
+	_, err := EasingByName("bounce")
+	if err == nil {
+		t.Error("Expected error for unknown easing")
+	}
+}
+
+func TestEasedLinearInterpolation(t *testing.T) {
+	interp := EasedLinearInterpolation(EaseIn)
+	AssertEqualsFloat64PointTolerance(t, interp(image.Point{0, 0}, image.Point{8, 4}, 0.5), Float64Point{2, 1}, .000001)
+}
@@ -0,0 +1,125 @@
+package gorph
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+// FitPolicy determines how an image is scaled to fit within bounds of a different
+// size.
+type FitPolicy int
+
+const (
+	// FitStretch scales each axis independently so the image exactly fills the
+	// bounds. The aspect ratio may change.
+	FitStretch FitPolicy = iota
+	// FitLetterbox uniformly scales the image so it lies entirely within the bounds,
+	// centered. Any uncovered area is left transparent.
+	FitLetterbox
+	// FitCrop uniformly scales the image so it covers the bounds entirely, centered.
+	// Any part of the image falling outside the bounds is cropped.
+	FitCrop
+)
+
+// fitMapping maps locations in one rectangle to another by scaling and translating.
+type fitMapping struct {
+	src    image.Rectangle
+	dst    image.Rectangle
+	scaleX float64
+	scaleY float64
+	offset Float64Point
+}
+
+func newFitMapping(src, dst image.Rectangle, policy FitPolicy) fitMapping {
+	scaleX := float64(dst.Dx()) / float64(src.Dx())
+	scaleY := float64(dst.Dy()) / float64(src.Dy())
+	switch policy {
+	case FitLetterbox:
+		scaleX = math.Min(scaleX, scaleY)
+		scaleY = scaleX
+	case FitCrop:
+		scaleX = math.Max(scaleX, scaleY)
+		scaleY = scaleX
+	}
+	offset := Float64Point{(float64(dst.Dx()) - scaleX*float64(src.Dx())) / 2, (float64(dst.Dy()) - scaleY*float64(src.Dy())) / 2}
+	return fitMapping{src, dst, scaleX, scaleY, offset}
+}
+
+func (f fitMapping) apply(pt Float64Point) Float64Point {
+	return Float64Point{float64(f.dst.Min.X) + f.offset.X + (pt.X-float64(f.src.Min.X))*f.scaleX, float64(f.dst.Min.Y) + f.offset.Y + (pt.Y-float64(f.src.Min.Y))*f.scaleY}
+}
+
+func (f fitMapping) invert(pt Float64Point) Float64Point {
+	return Float64Point{float64(f.src.Min.X) + (pt.X-float64(f.dst.Min.X)-f.offset.X)/f.scaleX, float64(f.src.Min.Y) + (pt.Y-float64(f.dst.Min.Y)-f.offset.Y)/f.scaleY}
+}
+
+// FitImage resamples an image into the given bounds, scaling it according to the fit
+// policy. The sampler is used to compute the color of every resulting pixel.
+func FitImage(img image.Image, bounds image.Rectangle, policy FitPolicy, sampler Sampler) (*image.RGBA64, error) {
+	srcBounds := img.Bounds()
+	if srcBounds.Empty() || bounds.Empty() {
+		return nil, errors.New("FitImage: Image bounds must not be empty")
+	}
+	mapping := newFitMapping(srcBounds, bounds, policy)
+	srcMax := Float64Point{float64(srcBounds.Max.X), float64(srcBounds.Max.Y)}
+	result := image.NewRGBA64(bounds)
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			srcPt := mapping.invert(Float64Point{float64(x) + 0.5, float64(y) + 0.5})
+			if srcPt.X < float64(srcBounds.Min.X) || srcPt.Y < float64(srcBounds.Min.Y) || srcPt.X >= srcMax.X || srcPt.Y >= srcMax.Y {
+				continue
+			}
+			result.Set(x, y, sampler(img, srcPt, BorderClamp))
+		}
+	}
+	return result, nil
+}
+
+// ConformImages prepares a start and destination image of different sizes for Morph,
+// which requires both images to share the same bounds. Both images are fit into the
+// given bounds with the fit policy, so passing start.Bounds() fits the destination
+// into the start image's bounds. An image already matching the bounds is returned as
+// is. The points of mGrid are expressed in each image's own coordinate space: start
+// points relative to the start image and destination points relative to the
+// destination image. If mGrid has reference bounds, both its start and destination
+// points are first rescaled from the reference bounds to their image's bounds. The
+// returned MorphGrid holds the same points mapped into the given bounds, which are
+// attached as its reference bounds.
+func ConformImages(start, dest image.Image, mGrid *MorphGrid, bounds image.Rectangle, policy FitPolicy, sampler Sampler) (image.Image, image.Image, *MorphGrid, error) {
+	if start.Bounds().Empty() || dest.Bounds().Empty() || bounds.Empty() {
+		return nil, nil, nil, errors.New("ConformImages: Image bounds must not be empty")
+	}
+	startMapping := newFitMapping(start.Bounds(), bounds, policy)
+	destMapping := newFitMapping(dest.Bounds(), bounds, policy)
+	startFn := startMapping.apply
+	destFn := destMapping.apply
+	if reference, ok := mGrid.ReferenceBounds(); ok {
+		startReference := newFitMapping(reference, start.Bounds(), FitStretch)
+		destReference := newFitMapping(reference, dest.Bounds(), FitStretch)
+		startFn = func(pt Float64Point) Float64Point {
+			return startMapping.apply(startReference.apply(pt))
+		}
+		destFn = func(pt Float64Point) Float64Point {
+			return destMapping.apply(destReference.apply(pt))
+		}
+	}
+	conformedStart, err := conformImage(start, bounds, policy, sampler)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	conformedDest, err := conformImage(dest, bounds, policy, sampler)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	conformedGrid := mGrid.mapped(startFn, destFn)
+	conformedGrid.SetReferenceBounds(bounds)
+	return conformedStart, conformedDest, conformedGrid, nil
+}
+
+func conformImage(img image.Image, bounds image.Rectangle, policy FitPolicy, sampler Sampler) (image.Image, error) {
+	if img.Bounds().Eq(bounds) {
+		return img, nil
+	}
+	return FitImage(img, bounds, policy, sampler)
+}
@@ -0,0 +1,82 @@
+package gorph
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFitMappingStretch(t *testing.T) {
+	m := newFitMapping(image.Rect(0, 0, 10, 20), image.Rect(0, 0, 20, 10), FitStretch)
+	AssertEqualsFloat64PointTolerance(t, m.apply(Float64Point{5, 10}), Float64Point{10, 5}, .000001)
+	AssertEqualsFloat64PointTolerance(t, m.invert(Float64Point{10, 5}), Float64Point{5, 10}, .000001)
+}
+
+func TestFitMappingLetterbox(t *testing.T) {
+	m := newFitMapping(image.Rect(0, 0, 10, 20), image.Rect(0, 0, 20, 20), FitLetterbox)
+	AssertEqualsFloat64PointTolerance(t, m.apply(Float64Point{0, 0}), Float64Point{5, 0}, .000001)
+	AssertEqualsFloat64PointTolerance(t, m.apply(Float64Point{10, 20}), Float64Point{15, 20}, .000001)
+}
+
+func TestFitMappingCrop(t *testing.T) {
+	m := newFitMapping(image.Rect(0, 0, 10, 20), image.Rect(0, 0, 20, 20), FitCrop)
+	AssertEqualsFloat64PointTolerance(t, m.apply(Float64Point{0, 0}), Float64Point{0, -10}, .000001)
+	AssertEqualsFloat64PointTolerance(t, m.apply(Float64Point{10, 20}), Float64Point{20, 30}, .000001)
+}
+
+func TestFitImageLetterbox(t *testing.T) {
+	img := image.NewRGBA64(image.Rect(0, 0, 2, 4))
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 4; j++ {
+			img.Set(i, j, color.RGBA64{0xffff, 0, 0, 0xffff})
+		}
+	}
+	result, err := FitImage(img, image.Rect(0, 0, 4, 4), FitLetterbox, NearestNeighborSampler)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsImageColor(t, result.At(0, 0), color.RGBA64{}, "Letterbox area not transparent")
+	AssertEqualsImageColor(t, result.At(1, 2), color.RGBA64{0xffff, 0, 0, 0xffff}, "Image area incorrect")
+	AssertEqualsImageColor(t, result.At(3, 3), color.RGBA64{}, "Letterbox area not transparent")
+}
+
+func TestConformImages(t *testing.T) {
+	start := image.NewRGBA64(image.Rect(0, 0, 8, 8))
+	dest := image.NewRGBA64(image.Rect(0, 0, 16, 16))
+	mGrid := NewMorphGrid()
+	mGrid.AddPoints(0, 0, image.Point{4, 4}, image.Point{8, 8})
+	conformedStart, conformedDest, conformedGrid, err := ConformImages(start, dest, mGrid, start.Bounds(), FitLetterbox, BilinearSampler)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if conformedStart != image.Image(start) {
+		t.Error("Start image matching the bounds was resampled")
+	}
+	if !conformedDest.Bounds().Eq(start.Bounds()) {
+		t.Error("Destination image was not fit into the bounds")
+	}
+	startPt, destPt, err := conformedGrid.Points(0, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsImagePoint(t, startPt, image.Point{4, 4})
+	AssertEqualsImagePoint(t, destPt, image.Point{4, 4})
+}
+
+func TestConformImagesReferenceBounds(t *testing.T) {
+	start := image.NewRGBA64(image.Rect(0, 0, 8, 8))
+	dest := image.NewRGBA64(image.Rect(0, 0, 16, 16))
+	mGrid := NewMorphGrid()
+	mGrid.AddPoints(0, 0, image.Point{1, 1}, image.Point{1, 1})
+	mGrid.SetReferenceBounds(image.Rect(0, 0, 2, 2))
+	_, _, conformedGrid, err := ConformImages(start, dest, mGrid, image.Rect(0, 0, 4, 4), FitStretch, BilinearSampler)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	startPt, destPt, err := conformedGrid.Points(0, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsImagePoint(t, startPt, image.Point{2, 2})
+	AssertEqualsImagePoint(t, destPt, image.Point{2, 2})
+}
@@ -0,0 +1,294 @@
+package gorph
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+// Add returns the vector sum p + q.
+func (p Float64Point) Add(q Float64Point) Float64Point {
+	return Float64Point{p.X + q.X, p.Y + q.Y}
+}
+
+// Sub returns the vector difference p - q.
+func (p Float64Point) Sub(q Float64Point) Float64Point {
+	return Float64Point{p.X - q.X, p.Y - q.Y}
+}
+
+// Mul returns p scaled by k.
+func (p Float64Point) Mul(k float64) Float64Point {
+	return Float64Point{p.X * k, p.Y * k}
+}
+
+// Dot returns the dot product of p and q.
+func (p Float64Point) Dot(q Float64Point) float64 {
+	return p.X*q.X + p.Y*q.Y
+}
+
+// Cross returns the z component of the cross product of p and q. It is positive when
+// q lies a quarter turn or less from p towards the y axis.
+func (p Float64Point) Cross(q Float64Point) float64 {
+	return p.X*q.Y - p.Y*q.X
+}
+
+// Len returns the length of p as a vector.
+func (p Float64Point) Len() float64 {
+	return math.Hypot(p.X, p.Y)
+}
+
+// Normalize returns the unit vector in the direction of p, or the zero vector if p
+// is zero.
+func (p Float64Point) Normalize() Float64Point {
+	length := p.Len()
+	if length == 0 {
+		return Float64Point{0, 0}
+	}
+	return Float64Point{p.X / length, p.Y / length}
+}
+
+// Rotate returns p rotated about the origin by angle radians, turning the x axis
+// towards the y axis. As the y axis of an image points down, a positive angle turns
+// clockwise on screen.
+func (p Float64Point) Rotate(angle float64) Float64Point {
+	sin, cos := math.Sincos(angle)
+	return Float64Point{p.X*cos - p.Y*sin, p.X*sin + p.Y*cos}
+}
+
+// Lerp returns the point the fraction t of the way from p to q, as
+// LinearInterpolation does.
+func (p Float64Point) Lerp(q Float64Point, t float64) Float64Point {
+	return LinearInterpolation(p, q, t)
+}
+
+// Eq reports whether p and q are within epsilon of each other in both x and y.
+func (p Float64Point) Eq(q Float64Point, epsilon float64) bool {
+	return math.Abs(p.X-q.X) <= epsilon && math.Abs(p.Y-q.Y) <= epsilon
+}
+
+// RoundingMode determines how a fractional coordinate is converted to an integer.
+type RoundingMode int
+
+const (
+	// RoundNearest rounds to the nearest integer, with halves rounded up. A location
+	// within a pixel rounds to the pixel's upper left corner or the one after it.
+	RoundNearest RoundingMode = iota
+	// RoundDown rounds towards negative infinity. A location rounds to the pixel that
+	// contains it.
+	RoundDown
+	// RoundUp rounds towards positive infinity.
+	RoundUp
+	// RoundTowardZero discards the fractional part.
+	RoundTowardZero
+)
+
+// round converts a coordinate to an integer by the rounding mode.
+func (mode RoundingMode) round(value float64) int {
+	switch mode {
+	case RoundDown:
+		return int(math.Floor(value))
+	case RoundUp:
+		return int(math.Ceil(value))
+	case RoundTowardZero:
+		return int(math.Trunc(value))
+	}
+	return int(math.Floor(value + 0.5))
+}
+
+// ToImagePoint converts p to an image.Point, rounding both coordinates by the mode.
+func (p Float64Point) ToImagePoint(mode RoundingMode) image.Point {
+	return image.Point{mode.round(p.X), mode.round(p.Y)}
+}
+
+// Float64Rect is a rectangle with double precision corners, the counterpart of
+// image.Rectangle. It contains the points with Min.X <= X < Max.X and
+// Min.Y <= Y < Max.Y.
+type Float64Rect struct {
+	Min Float64Point
+	Max Float64Point
+}
+
+// ToFloat64Rect converts an image.Rectangle to a Float64Rect covering the same
+// pixels.
+func ToFloat64Rect(r image.Rectangle) Float64Rect {
+	return Float64Rect{ToFloat64Point(r.Min), ToFloat64Point(r.Max)}
+}
+
+// BoundingRect returns the smallest Float64Rect holding every point, with the points
+// of the largest x or y lying on its edge. It is empty if there are no points.
+func BoundingRect(pts []Float64Point) Float64Rect {
+	if len(pts) == 0 {
+		return Float64Rect{}
+	}
+	r := Float64Rect{pts[0], pts[0]}
+	for _, pt := range pts[1:] {
+		r.Min = Float64Point{math.Min(r.Min.X, pt.X), math.Min(r.Min.Y, pt.Y)}
+		r.Max = Float64Point{math.Max(r.Max.X, pt.X), math.Max(r.Max.Y, pt.Y)}
+	}
+	return r
+}
+
+// Dx returns the width of the rectangle.
+func (r Float64Rect) Dx() float64 {
+	return r.Max.X - r.Min.X
+}
+
+// Dy returns the height of the rectangle.
+func (r Float64Rect) Dy() float64 {
+	return r.Max.Y - r.Min.Y
+}
+
+// Empty reports whether the rectangle contains no points.
+func (r Float64Rect) Empty() bool {
+	return !(r.Min.X < r.Max.X && r.Min.Y < r.Max.Y)
+}
+
+// Canon returns the rectangle with its corners swapped as needed so that Min is at
+// the upper left of Max.
+func (r Float64Rect) Canon() Float64Rect {
+	if r.Max.X < r.Min.X {
+		r.Min.X, r.Max.X = r.Max.X, r.Min.X
+	}
+	if r.Max.Y < r.Min.Y {
+		r.Min.Y, r.Max.Y = r.Max.Y, r.Min.Y
+	}
+	return r
+}
+
+// Contains reports whether the point lies within the rectangle.
+func (r Float64Rect) Contains(pt Float64Point) bool {
+	return r.Min.X <= pt.X && pt.X < r.Max.X && r.Min.Y <= pt.Y && pt.Y < r.Max.Y
+}
+
+// Center returns the point halfway between the corners of the rectangle.
+func (r Float64Rect) Center() Float64Point {
+	return r.Min.Lerp(r.Max, 0.5)
+}
+
+// Corners returns the four corners of the rectangle, clockwise on screen from Min.
+func (r Float64Rect) Corners() [4]Float64Point {
+	return [4]Float64Point{r.Min, {r.Max.X, r.Min.Y}, r.Max, {r.Min.X, r.Max.Y}}
+}
+
+// Intersect returns the largest rectangle contained by both r and s, or the zero
+// rectangle if they do not overlap.
+func (r Float64Rect) Intersect(s Float64Rect) Float64Rect {
+	result := Float64Rect{
+		Float64Point{math.Max(r.Min.X, s.Min.X), math.Max(r.Min.Y, s.Min.Y)},
+		Float64Point{math.Min(r.Max.X, s.Max.X), math.Min(r.Max.Y, s.Max.Y)},
+	}
+	if result.Empty() {
+		return Float64Rect{}
+	}
+	return result
+}
+
+// Union returns the smallest rectangle containing both r and s. An empty rectangle
+// contributes nothing.
+func (r Float64Rect) Union(s Float64Rect) Float64Rect {
+	if r.Empty() {
+		return s
+	} else if s.Empty() {
+		return r
+	}
+	return Float64Rect{
+		Float64Point{math.Min(r.Min.X, s.Min.X), math.Min(r.Min.Y, s.Min.Y)},
+		Float64Point{math.Max(r.Max.X, s.Max.X), math.Max(r.Max.Y, s.Max.Y)},
+	}
+}
+
+// ToRectangle converts the rectangle to an image.Rectangle, rounding both corners by
+// the mode.
+func (r Float64Rect) ToRectangle(mode RoundingMode) image.Rectangle {
+	return image.Rectangle{r.Min.ToImagePoint(mode), r.Max.ToImagePoint(mode)}
+}
+
+// Enclosing returns the smallest image.Rectangle containing every pixel the
+// rectangle touches, rounding Min down and Max up.
+func (r Float64Rect) Enclosing() image.Rectangle {
+	return image.Rectangle{r.Min.ToImagePoint(RoundDown), r.Max.ToImagePoint(RoundUp)}
+}
+
+// Affine is a 2x3 matrix holding an affine transform of the plane, stored by rows.
+// It maps (x, y) to (A[0]x + A[1]y + A[2], A[3]x + A[4]y + A[5]). The zero value is
+// not the identity; use IdentityAffine.
+type Affine [6]float64
+
+// IdentityAffine returns the transform that leaves every point in place.
+func IdentityAffine() Affine {
+	return Affine{1, 0, 0, 0, 1, 0}
+}
+
+// TranslateAffine returns the transform that moves every point by (dx, dy).
+func TranslateAffine(dx, dy float64) Affine {
+	return Affine{1, 0, dx, 0, 1, dy}
+}
+
+// ScaleAffine returns the transform that scales about the origin by sx horizontally
+// and sy vertically.
+func ScaleAffine(sx, sy float64) Affine {
+	return Affine{sx, 0, 0, 0, sy, 0}
+}
+
+// RotateAffine returns the transform that rotates about the origin by angle radians,
+// as Float64Point.Rotate does.
+func RotateAffine(angle float64) Affine {
+	sin, cos := math.Sincos(angle)
+	return Affine{cos, -sin, 0, sin, cos, 0}
+}
+
+// ShearAffine returns the transform that shears x by kx times y, and y by ky times x.
+func ShearAffine(kx, ky float64) Affine {
+	return Affine{1, kx, 0, ky, 1, 0}
+}
+
+// Apply transforms a point.
+func (a Affine) Apply(pt Float64Point) Float64Point {
+	return Float64Point{a[0]*pt.X + a[1]*pt.Y + a[2], a[3]*pt.X + a[4]*pt.Y + a[5]}
+}
+
+// ApplyVector transforms a vector, which ignores the translation.
+func (a Affine) ApplyVector(v Float64Point) Float64Point {
+	return Float64Point{a[0]*v.X + a[1]*v.Y, a[3]*v.X + a[4]*v.Y}
+}
+
+// ApplyRect returns the bounding rectangle of the transformed corners of r.
+func (a Affine) ApplyRect(r Float64Rect) Float64Rect {
+	corners := r.Corners()
+	for i := range corners {
+		corners[i] = a.Apply(corners[i])
+	}
+	return BoundingRect(corners[:])
+}
+
+// Compose returns the transform that applies b and then a, the matrix product ab.
+func (a Affine) Compose(b Affine) Affine {
+	return Affine{
+		a[0]*b[0] + a[1]*b[3], a[0]*b[1] + a[1]*b[4], a[0]*b[2] + a[1]*b[5] + a[2],
+		a[3]*b[0] + a[4]*b[3], a[3]*b[1] + a[4]*b[4], a[3]*b[2] + a[4]*b[5] + a[5],
+	}
+}
+
+// Then returns the transform that applies a and then b, which reads in order when
+// chaining transforms.
+func (a Affine) Then(b Affine) Affine {
+	return b.Compose(a)
+}
+
+// Det returns the determinant of the linear part of the transform, the factor by
+// which it scales areas.
+func (a Affine) Det() float64 {
+	return a[0]*a[4] - a[1]*a[3]
+}
+
+// Invert returns the transform that undoes a. Returns an error if a is singular,
+// collapsing the plane onto a line or point.
+func (a Affine) Invert() (Affine, error) {
+	det := a.Det()
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Affine{}, errors.New("Invert: Affine transform is singular")
+	}
+	inv0, inv1 := a[4]/det, -a[1]/det
+	inv3, inv4 := -a[3]/det, a[0]/det
+	return Affine{inv0, inv1, -(inv0*a[2] + inv1*a[5]), inv3, inv4, -(inv3*a[2] + inv4*a[5])}, nil
+}
@@ -0,0 +1,80 @@
+package gorph
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestFloat64PointVectorOps(t *testing.T) {
+	p, q := Float64Point{3, 4}, Float64Point{1, -2}
+	AssertEqualsFloat64Point(t, p.Add(q), Float64Point{4, 2}, "Add incorrect")
+	AssertEqualsFloat64Point(t, p.Sub(q), Float64Point{2, 6}, "Sub incorrect")
+	AssertEqualsFloat64Point(t, p.Mul(2), Float64Point{6, 8}, "Mul incorrect")
+	AssertEqualsFloat64Slice(t, []float64{p.Dot(q), p.Cross(q), p.Len()}, []float64{-5, -10, 5})
+	AssertEqualsFloat64Point(t, p.Normalize(), Float64Point{0.6, 0.8}, "Normalize incorrect")
+	AssertEqualsFloat64Point(t, Float64Point{}.Normalize(), Float64Point{}, "Normalizing zero should give zero")
+	AssertEqualsFloat64PointTolerance(t, Float64Point{1, 0}.Rotate(math.Pi/2), Float64Point{0, 1}, 1e-12, "Rotate incorrect")
+	AssertEqualsFloat64Point(t, p.Lerp(q, 0.5), Float64Point{2, 1}, "Lerp incorrect")
+	if !p.Eq(Float64Point{3.0005, 3.9995}, 0.001) || p.Eq(q, 0.001) {
+		t.Error("Eq incorrect")
+	}
+}
+
+func TestFloat64PointToImagePoint(t *testing.T) {
+	pt := Float64Point{-1.5, 2.5}
+	AssertEqualsImagePoint(t, pt.ToImagePoint(RoundNearest), image.Point{-1, 3})
+	AssertEqualsImagePoint(t, pt.ToImagePoint(RoundDown), image.Point{-2, 2})
+	AssertEqualsImagePoint(t, pt.ToImagePoint(RoundUp), image.Point{-1, 3})
+	AssertEqualsImagePoint(t, pt.ToImagePoint(RoundTowardZero), image.Point{-1, 2})
+}
+
+func TestFloat64Rect(t *testing.T) {
+	r := ToFloat64Rect(image.Rect(0, 0, 4, 2))
+	if r.Dx() != 4 || r.Dy() != 2 || r.Empty() {
+		t.Error("Converted rectangle incorrect", r)
+	}
+	if !r.Contains(Float64Point{0, 1.9}) || r.Contains(Float64Point{4, 1}) {
+		t.Error("Contains incorrect")
+	}
+	s := Float64Rect{Float64Point{3.5, 1.5}, Float64Point{1.25, -0.5}}.Canon()
+	AssertEqualsFloat64Point(t, s.Min, Float64Point{1.25, -0.5}, "Canon incorrect")
+	AssertEqualsFloat64Point(t, r.Intersect(s).Min, Float64Point{1.25, 0}, "Intersect incorrect")
+	AssertEqualsFloat64Point(t, r.Union(s).Min, Float64Point{0, -0.5}, "Union incorrect")
+	if !r.Intersect(Float64Rect{Float64Point{5, 5}, Float64Point{6, 6}}).Empty() {
+		t.Error("Disjoint rectangles should not intersect")
+	}
+	if enclosing := s.Enclosing(); enclosing != image.Rect(1, -1, 4, 2) {
+		t.Error("Enclosing rectangle incorrect", enclosing)
+	}
+	if rounded := s.ToRectangle(RoundNearest); rounded != image.Rect(1, 0, 4, 2) {
+		t.Error("Rounded rectangle incorrect", rounded)
+	}
+	bounds := BoundingRect([]Float64Point{{1, 5}, {-2, 3}, {4, 4}})
+	AssertEqualsFloat64Point(t, bounds.Min, Float64Point{-2, 3}, "Bounding rectangle incorrect")
+	AssertEqualsFloat64Point(t, bounds.Max, Float64Point{4, 5}, "Bounding rectangle incorrect")
+}
+
+func TestAffine(t *testing.T) {
+	a := IdentityAffine().Then(ScaleAffine(2, 3)).Then(RotateAffine(math.Pi / 2)).Then(TranslateAffine(10, 0))
+	AssertEqualsFloat64PointTolerance(t, a.Apply(Float64Point{1, 1}), Float64Point{7, 2}, 1e-12, "Chained transform incorrect")
+	AssertEqualsFloat64PointTolerance(t, a.ApplyVector(Float64Point{1, 0}), Float64Point{0, 2}, 1e-12, "Vector transform incorrect")
+	b := TranslateAffine(10, 0).Compose(RotateAffine(math.Pi / 2).Compose(ScaleAffine(2, 3)))
+	for _, pt := range []Float64Point{{0, 0}, {1, 0}, {0, 1}} {
+		AssertEqualsFloat64PointTolerance(t, a.Apply(pt), b.Apply(pt), 1e-12, "Then and Compose disagree")
+	}
+	inv, err := a.Invert()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pt := Float64Point{-3, 8.5}
+	AssertEqualsFloat64PointTolerance(t, inv.Apply(a.Apply(pt)), pt, 1e-12, "Inverse does not undo the transform")
+	AssertEqualsFloat64PointTolerance(t, a.Compose(inv).Apply(pt), pt, 1e-12, "Composing with the inverse is not the identity")
+	_, err = ScaleAffine(1, 0).Invert()
+	if err == nil {
+		t.Error("Expected error inverting a singular transform")
+	}
+	rect := RotateAffine(math.Pi / 4).ApplyRect(Float64Rect{Float64Point{0, 0}, Float64Point{2, 2}})
+	AssertEqualsFloat64PointTolerance(t, rect.Min, Float64Point{-math.Sqrt2, 0}, 1e-12, "Transformed rectangle incorrect")
+	AssertEqualsFloat64PointTolerance(t, rect.Max, Float64Point{math.Sqrt2, 2 * math.Sqrt2}, 1e-12, "Transformed rectangle incorrect")
+}
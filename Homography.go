@@ -0,0 +1,301 @@
+package gorph
+
+import (
+	"errors"
+	"image"
+	"math"
+	"strconv"
+)
+
+// Homography is a 3x3 matrix holding a projective transform of the plane, stored by
+// rows, such as the perspective seen by a camera of a flat poster or page. It maps
+// (x, y) to (u/w, v/w), where (u, v, w) is the product of the matrix and (x, y, 1).
+// Homographies are only defined up to scale, so a matrix and any nonzero multiple of
+// it are the same transform.
+type Homography [9]float64
+
+// IdentityHomography returns the homography that leaves every point in place.
+func IdentityHomography() Homography {
+	return Homography{1, 0, 0, 0, 1, 0, 0, 0, 1}
+}
+
+// HomographyFromAffine returns the homography of an affine transform.
+func HomographyFromAffine(a Affine) Homography {
+	return Homography{a[0], a[1], a[2], a[3], a[4], a[5], 0, 0, 1}
+}
+
+// Apply transforms a point. A point mapped to infinity, on the horizon of the
+// transform, has infinite or NaN coordinates.
+func (h Homography) Apply(pt Float64Point) Float64Point {
+	pt, _ = h.project(pt)
+	return pt
+}
+
+// project transforms a point, also returning the w it was divided by. The sign of w
+// tells which side of the horizon of the transform the point lies on.
+func (h Homography) project(pt Float64Point) (Float64Point, float64) {
+	w := h[6]*pt.X + h[7]*pt.Y + h[8]
+	return Float64Point{(h[0]*pt.X + h[1]*pt.Y + h[2]) / w, (h[3]*pt.X + h[4]*pt.Y + h[5]) / w}, w
+}
+
+// Compose returns the homography that applies b and then h, the matrix product hb.
+func (h Homography) Compose(b Homography) Homography {
+	var result Homography
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			for k := 0; k < 3; k++ {
+				result[3*row+col] += h[3*row+k] * b[3*k+col]
+			}
+		}
+	}
+	return result
+}
+
+// Det returns the determinant of the matrix.
+func (h Homography) Det() float64 {
+	return h[0]*(h[4]*h[8]-h[5]*h[7]) - h[1]*(h[3]*h[8]-h[5]*h[6]) + h[2]*(h[3]*h[7]-h[4]*h[6])
+}
+
+// Invert returns the homography that undoes h, mapping the points it produces back to
+// where they came from. Returns an error if h is singular.
+func (h Homography) Invert() (Homography, error) {
+	det := h.Det()
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Homography{}, errors.New("Invert: Homography is singular")
+	}
+	adjugate := Homography{
+		h[4]*h[8] - h[5]*h[7], h[2]*h[7] - h[1]*h[8], h[1]*h[5] - h[2]*h[4],
+		h[5]*h[6] - h[3]*h[8], h[0]*h[8] - h[2]*h[6], h[2]*h[3] - h[0]*h[5],
+		h[3]*h[7] - h[4]*h[6], h[1]*h[6] - h[0]*h[7], h[0]*h[4] - h[1]*h[3],
+	}
+	for i := range adjugate {
+		adjugate[i] /= det
+	}
+	return adjugate, nil
+}
+
+// EstimateHomography computes the homography that best maps each source point onto
+// its destination point, from four or more correspondences. It uses the direct
+// linear transform, with both sets of points first normalized to be centered on the
+// origin at an average distance of the square root of two, which keeps the solution
+// accurate for points in pixel coordinates. With exactly four correspondences the
+// mapping is exact. The result is scaled so its last entry is 1 where possible.
+// Returns an error if the point counts differ, there are fewer than four, or the
+// points are degenerate, such as three of four lying on a line.
+func EstimateHomography(src, dst []Float64Point) (Homography, error) {
+	if len(src) != len(dst) {
+		return Homography{}, errors.New("EstimateHomography: Number of source points " + strconv.Itoa(len(src)) + " does not match the number of destination points " + strconv.Itoa(len(dst)))
+	}
+	if len(src) < 4 {
+		return Homography{}, errors.New("EstimateHomography: Less than four point correspondences passed in")
+	}
+	srcNorm, srcOk := normalizingAffine(src)
+	dstNorm, dstOk := normalizingAffine(dst)
+	if !srcOk || !dstOk {
+		return Homography{}, errors.New("EstimateHomography: Points are degenerate")
+	}
+	// Accumulate the normal matrix of the DLT equations, whose eigenvector of the
+	// smallest eigenvalue is the least squares solution
+	var normal [9][9]float64
+	for i := range src {
+		p := srcNorm.Apply(src[i])
+		q := dstNorm.Apply(dst[i])
+		rows := [2][9]float64{
+			{-p.X, -p.Y, -1, 0, 0, 0, q.X * p.X, q.X * p.Y, q.X},
+			{0, 0, 0, -p.X, -p.Y, -1, q.Y * p.X, q.Y * p.Y, q.Y},
+		}
+		for _, row := range rows {
+			for j := 0; j < 9; j++ {
+				for k := 0; k < 9; k++ {
+					normal[j][k] += row[j] * row[k]
+				}
+			}
+		}
+	}
+	values, vectors := symmetricEigen(normal)
+	smallest := 0
+	for i := range values {
+		if values[i] < values[smallest] {
+			smallest = i
+		}
+	}
+	var hNorm Homography
+	for i := range hNorm {
+		hNorm[i] = vectors[i][smallest]
+	}
+	dstDenorm, err := dstNorm.Invert()
+	if err != nil {
+		return Homography{}, errors.New("EstimateHomography: " + err.Error())
+	}
+	h := HomographyFromAffine(dstDenorm).Compose(hNorm).Compose(HomographyFromAffine(srcNorm))
+	// A rank deficient solution collapses the plane, so the points did not pin down
+	// a transform
+	scale := 0.0
+	for _, value := range h {
+		scale = math.Max(scale, math.Abs(value))
+	}
+	if math.Abs(hNorm.Det()) < 1e-9 || scale == 0 {
+		return Homography{}, errors.New("EstimateHomography: Points are degenerate")
+	}
+	if math.Abs(h[8]) > 1e-12*scale {
+		scale = h[8]
+	}
+	for i := range h {
+		h[i] /= scale
+	}
+	return h, nil
+}
+
+// normalizingAffine returns the similarity transform moving the centroid of the
+// points to the origin and scaling their average distance from it to the square
+// root of two. Returns false if the points all coincide.
+func normalizingAffine(pts []Float64Point) (Affine, bool) {
+	var centroid Float64Point
+	for _, pt := range pts {
+		centroid = centroid.Add(pt)
+	}
+	centroid = centroid.Mul(1 / float64(len(pts)))
+	meanDistance := 0.0
+	for _, pt := range pts {
+		meanDistance += Distance(pt, centroid)
+	}
+	meanDistance /= float64(len(pts))
+	if meanDistance == 0 {
+		return Affine{}, false
+	}
+	scale := math.Sqrt2 / meanDistance
+	return TranslateAffine(-centroid.X, -centroid.Y).Then(ScaleAffine(scale, scale)), true
+}
+
+// symmetricEigen computes the eigenvalues and eigenvectors of a symmetric matrix by
+// the cyclic Jacobi method. Column i of the vectors belongs to value i.
+func symmetricEigen(m [9][9]float64) (values [9]float64, vectors [9][9]float64) {
+	const n = 9
+	for i := 0; i < n; i++ {
+		vectors[i][i] = 1
+	}
+	for sweep := 0; sweep < 64; sweep++ {
+		offDiagonal := 0.0
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				offDiagonal += m[p][q] * m[p][q]
+			}
+		}
+		if offDiagonal < 1e-30 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if m[p][q] == 0 {
+					continue
+				}
+				// Rotate rows and columns p and q to zero m[p][q]
+				theta := (m[q][q] - m[p][p]) / (2 * m[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					mkp, mkq := m[k][p], m[k][q]
+					m[k][p], m[k][q] = c*mkp-s*mkq, s*mkp+c*mkq
+				}
+				for k := 0; k < n; k++ {
+					mpk, mqk := m[p][k], m[q][k]
+					m[p][k], m[q][k] = c*mpk-s*mqk, s*mpk+c*mqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := vectors[k][p], vectors[k][q]
+					vectors[k][p], vectors[k][q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		values[i] = m[i][i]
+	}
+	return values, vectors
+}
+
+// Perspective resamples an image by a homography, moving each location in the
+// original image to h.Apply of that location. Every resulting pixel is sampled at
+// the inverse homography of its center with the sampler and border mode of the
+// options, and pixels whose centers map from behind the camera are left
+// transparent. Expanding the bounds requires the whole image to lie in front of the
+// camera. Returns an error if the image is empty, the homography is singular, or the
+// options ask for a three-shear rotation.
+func Perspective(img image.Image, h Homography, opts TransformOptions) (*image.RGBA64, error) {
+	srcBounds := img.Bounds()
+	if srcBounds.Empty() {
+		return nil, errors.New("Perspective: Image bounds must not be empty")
+	}
+	if opts.ThreeShear {
+		return nil, errors.New("Perspective: Three-shear rotation only applies to Transform")
+	}
+	inverse, err := h.Invert()
+	if err != nil {
+		return nil, errors.New("Perspective: " + err.Error())
+	}
+	bounds := srcBounds
+	if opts.Bounds == BoundsExpand {
+		corners := ToFloat64Rect(srcBounds).Corners()
+		var sign float64
+		for i, corner := range corners {
+			pt, w := h.project(corner)
+			if w == 0 || (i > 0 && math.Signbit(w) != math.Signbit(sign)) {
+				return nil, errors.New("Perspective: Image crosses the horizon of the homography, so its bounds cannot be expanded")
+			}
+			sign = w
+			corners[i] = pt
+		}
+		bounds = BoundingRect(corners[:]).Enclosing()
+	}
+	_, w := h.project(ToFloat64Rect(srcBounds).Center())
+	return perspectiveWarp(img, inverse, math.Signbit(w), bounds, opts), nil
+}
+
+// perspectiveWarp samples every pixel of bounds from img at the inverse homography of
+// the pixel's center. The forward homography maps the points in front of the camera
+// with a w whose sign bit is front. The inverse maps their images with a w of
+// the reciprocal, so of the same sign.
+func perspectiveWarp(img image.Image, inverse Homography, front bool, bounds image.Rectangle, opts TransformOptions) *image.RGBA64 {
+	sampler := opts.Sampler
+	if sampler == nil {
+		sampler = BilinearSampler
+	}
+	result := image.NewRGBA64(bounds)
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			srcPt, w := inverse.project(Float64Point{float64(x) + 0.5, float64(y) + 0.5})
+			if w == 0 || math.Signbit(w) != front {
+				continue
+			}
+			result.Set(x, y, sampler(img, srcPt, opts.Border))
+		}
+	}
+	return result
+}
+
+// StraightenQuad maps the quadrilateral of an image with the given corners onto the
+// rectangle of bounds, such as to straighten a photographed poster or page. The
+// corners run clockwise on screen from the one that becomes Min of the bounds. The
+// result has the given bounds, and is sampled as Perspective does. Returns an error
+// if the image or bounds are empty, the corners are degenerate, or the options ask
+// for a three-shear rotation.
+func StraightenQuad(img image.Image, corners [4]Float64Point, bounds image.Rectangle, opts TransformOptions) (*image.RGBA64, error) {
+	if img.Bounds().Empty() || bounds.Empty() {
+		return nil, errors.New("StraightenQuad: Image and bounds must not be empty")
+	}
+	if opts.ThreeShear {
+		return nil, errors.New("StraightenQuad: Three-shear rotation only applies to Transform")
+	}
+	target := ToFloat64Rect(bounds).Corners()
+	h, err := EstimateHomography(corners[:], target[:])
+	if err != nil {
+		return nil, errors.New("StraightenQuad: " + err.Error())
+	}
+	inverse, err := h.Invert()
+	if err != nil {
+		return nil, errors.New("StraightenQuad: " + err.Error())
+	}
+	_, w := h.project(BoundingRect(corners[:]).Center())
+	return perspectiveWarp(img, inverse, math.Signbit(w), bounds, opts), nil
+}
@@ -0,0 +1,127 @@
+package gorph
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEstimateHomographyFourPoints(t *testing.T) {
+	src := []Float64Point{{0, 0}, {100, 0}, {100, 50}, {0, 50}}
+	dst := []Float64Point{{10, 20}, {90, 5}, {120, 80}, {-5, 60}}
+	h, err := EstimateHomography(src, dst)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for i := range src {
+		AssertEqualsFloat64PointTolerance(t, h.Apply(src[i]), dst[i], 1e-6, "Correspondence is not mapped exactly")
+	}
+	if h[8] != 1 {
+		t.Error("Homography is not scaled to a last entry of 1", h)
+	}
+	inverse, err := h.Invert()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pt := Float64Point{37.5, 12.25}
+	AssertEqualsFloat64PointTolerance(t, inverse.Apply(h.Apply(pt)), pt, 1e-9, "Inverse does not map the point back")
+	AssertEqualsFloat64PointTolerance(t, h.Compose(inverse).Apply(pt), pt, 1e-9, "Composing with the inverse is not the identity")
+}
+
+func TestEstimateHomographyLeastSquares(t *testing.T) {
+	expected := HomographyFromAffine(RotateAffine(0.3).Then(TranslateAffine(5, -2)))
+	expected[6] = 0.001
+	var src, dst []Float64Point
+	for x := 0.0; x <= 200; x += 50 {
+		for y := 0.0; y <= 100; y += 50 {
+			src = append(src, Float64Point{x, y})
+			dst = append(dst, expected.Apply(Float64Point{x, y}))
+		}
+	}
+	h, err := EstimateHomography(src, dst)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pt := Float64Point{120, 30}
+	AssertEqualsFloat64PointTolerance(t, h.Apply(pt), expected.Apply(pt), 1e-6, "Estimated homography differs")
+}
+
+func TestEstimateHomographyErrors(t *testing.T) {
+	square := []Float64Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+	if _, err := EstimateHomography(square[:3], square[:3]); err == nil {
+		t.Error("Expected error for three correspondences")
+	}
+	if _, err := EstimateHomography(square, square[:3]); err == nil {
+		t.Error("Expected error for mismatched point counts")
+	}
+	collinear := []Float64Point{{0, 0}, {1, 1}, {2, 2}, {3, 3}}
+	if _, err := EstimateHomography(collinear, square); err == nil {
+		t.Error("Expected error for collinear points")
+	}
+	threeCollinear := []Float64Point{{0, 0}, {1, 0}, {2, 0}, {0, 1}}
+	if _, err := EstimateHomography(threeCollinear, square); err == nil {
+		t.Error("Expected error for three collinear points")
+	}
+}
+
+func TestPerspectiveAffine(t *testing.T) {
+	img := gradientImage(6, 4)
+	a := TranslateAffine(1, 2)
+	expected, err := Transform(img, a, TransformOptions{Sampler: NearestNeighborSampler, Bounds: BoundsExpand})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	result, err := Perspective(img, HomographyFromAffine(a), TransformOptions{Sampler: NearestNeighborSampler, Bounds: BoundsExpand})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !result.Bounds().Eq(expected.Bounds()) {
+		t.Fatal("Expanded bounds incorrect", result.Bounds())
+	}
+	for x := 1; x < 7; x++ {
+		for y := 2; y < 6; y++ {
+			AssertEqualsImageColor(t, result.At(x, y), expected.At(x, y), "Pixel differs from the affine transform")
+		}
+	}
+	negated := HomographyFromAffine(a)
+	for i := range negated {
+		negated[i] = -negated[i]
+	}
+	result, err = Perspective(img, negated, TransformOptions{Sampler: NearestNeighborSampler})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsImageColor(t, result.At(3, 3), img.At(2, 1), "Negated homography is not the same transform")
+}
+
+func TestStraightenQuad(t *testing.T) {
+	// A red quadrilateral on a transparent background
+	corners := [4]Float64Point{{4, 2}, {28, 6}, {26, 30}, {2, 24}}
+	h, err := EstimateHomography([]Float64Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}, corners[:])
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inverse, _ := h.Invert()
+	img := image.NewRGBA64(image.Rect(0, 0, 32, 32))
+	for x := 0; x < 32; x++ {
+		for y := 0; y < 32; y++ {
+			unit := inverse.Apply(Float64Point{float64(x) + 0.5, float64(y) + 0.5})
+			if unit.X > 0 && unit.X < 1 && unit.Y > 0 && unit.Y < 1 {
+				img.Set(x, y, color.RGBA64{0xffff, 0, 0, 0xffff})
+			}
+		}
+	}
+	result, err := StraightenQuad(img, corners, image.Rect(0, 0, 10, 10), TransformOptions{Sampler: NearestNeighborSampler})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			AssertEqualsImageColor(t, result.At(x, y), color.RGBA64{0xffff, 0, 0, 0xffff}, "Straightened quad does not fill the bounds")
+		}
+	}
+	_, err = StraightenQuad(img, corners, image.Rect(0, 0, 10, 10), TransformOptions{ThreeShear: true})
+	if err == nil {
+		t.Error("Expected error for a three-shear straightening")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"image"
 	"math"
+	"sort"
+	"strconv"
 )
 
 // LinearInterpolationImagePoints linearly interpolates the Float64Point between two image points based
@@ -40,49 +42,99 @@ func CubicCatmullRomInterpolationImagePoints(points []image.Point, alpha float64
 // points are passed in, linear interpolation occurs instead. The
 // alpha parameter dictates the kind of Catmull-Rom spline generated; a value of
 // 0 yields a Uniform curve, a value of 0.5 yields a Centripetal curve (which will
-// not form loops), and a value of 1.0 creates a Chordal curve.
+// not form loops), and a value of 1.0 creates a Chordal curve. The end control
+// points are extrapolated; see CubicCatmullRomInterpolationWith for other end
+// conditions.
 func CubicCatmullRomInterpolation(points []Float64Point, alpha float64, totSteps int) ([]Float64Point, error) {
+	return CubicCatmullRomInterpolationWith(points, CatmullRomOptions{Alpha: alpha}, totSteps)
+}
+
+// EndCondition selects how the phantom control point beyond an end of a
+// Catmull-Rom spline is chosen.
+type EndCondition int
+
+const (
+	// EndExtrapolate places the phantom point twice the length of the last segment
+	// beyond the end point. It is the default.
+	EndExtrapolate EndCondition = iota
+	// EndReflect reflects the neighbor of the end point through the end point.
+	EndReflect
+	// EndDuplicate repeats the end point, which flattens the curve as it arrives.
+	EndDuplicate
+	// EndNatural chooses the phantom point so that the second derivative vanishes
+	// at the end point. The condition is exact for uniform curves and approximate
+	// otherwise.
+	EndNatural
+	// EndPhantom uses the phantom point supplied in CatmullRomOptions.
+	EndPhantom
+)
+
+// CatmullRomOptions configures CubicCatmullRomInterpolationWith.
+type CatmullRomOptions struct {
+	// Alpha dictates the kind of Catmull-Rom spline generated: 0 yields a Uniform
+	// curve, 0.5 a Centripetal curve and 1 a Chordal curve.
+	Alpha float64
+	// Start and End are the end conditions at the first and last points.
+	Start EndCondition
+	End   EndCondition
+	// StartPhantom and EndPhantom are the phantom control points used by
+	// EndPhantom.
+	StartPhantom *Float64Point
+	EndPhantom   *Float64Point
+	// Closed joins the last point back to the first, making a periodic curve whose
+	// last point is the first point. The end conditions are then ignored.
+	Closed bool
+}
+
+// CubicCatmullRomInterpolationWith computes the Catmull-Rom spline from a given set
+// of points like CubicCatmullRomInterpolation, with configurable end conditions
+// or as a closed curve. A closed curve needs at least three points.
+func CubicCatmullRomInterpolationWith(points []Float64Point, opts CatmullRomOptions, totSteps int) ([]Float64Point, error) {
 	nPoints := len(points)
 	if nPoints < 2 {
 		return nil, errors.New("CubicCatmullRomInterpolation: Less than two points passed in")
 	}
-	if alpha < 0 || alpha > 1 {
+	if opts.Alpha < 0 || opts.Alpha > 1 {
 		return nil, errors.New("CubicCatmullRomInterpolation: Alpha must be in the range of [0.0, 1.0]")
 	}
 	if totSteps < 2 {
 		return nil, errors.New("CubicCatmullRomInterpolation: Total steps must be 2 or greater")
 	}
 	resultPts := make([]Float64Point, 0, totSteps)
-	if nPoints == 2 {
-		resultPts[0] = points[0]
+	if nPoints == 2 && !opts.Closed {
+		resultPts = append(resultPts, points[0])
 		for i := 1; i < totSteps-1; i++ {
-			resultPts[i] = LinearInterpolation(points[0], points[1], float64(i)/float64(totSteps))
+			resultPts = append(resultPts, LinearInterpolation(points[0], points[1], float64(i)/float64(totSteps)))
 		}
-		resultPts[totSteps-1] = points[1]
+		resultPts = append(resultPts, points[1])
 		return resultPts, nil
 	}
+	controls, err := catmullRomControls(points, opts)
+	if err != nil {
+		return nil, err
+	}
+	nSegments := len(controls) - 3
+
 	// Precompute the number of "steps" to take between each point
-	stepsAtRange := make([]uint, nPoints-1)
+	stepsAtRange := make([]uint, nSegments)
 	sumDist := 0.0
-	for i := 0; i < nPoints-1; i++ {
-		sumDist += Distance(points[i], points[i+1])
+	for i := 0; i < nSegments; i++ {
+		sumDist += Distance(controls[i+1], controls[i+2])
 	}
-	for i := 0; i < nPoints-1; i++ {
-		stepsAtRange[i] = uint(math.Floor((float64(totSteps)-0.5)*Distance(points[i], points[i+1])/sumDist + 0.5))
+	for i := 0; i < nSegments; i++ {
+		stepsAtRange[i] = uint(math.Floor((float64(totSteps)-0.5)*Distance(controls[i+1], controls[i+2])/sumDist + 0.5))
 	}
 
-	// Linearly extrapolate
-	postpendControl := Float64Point{points[nPoints-1].X + 2*(points[nPoints-1].X-points[nPoints-2].X), points[nPoints-1].Y + 2*(points[nPoints-1].Y-points[nPoints-2].Y)}
-	pt0 := Float64Point{points[0].X - 2*(points[1].X-points[0].X), points[0].Y - 2*(points[1].Y-points[0].Y)} // "prependControl"
-	pt1 := points[0]
-	pt2 := points[1] // Want to iterate until this is nPoints (inclusive)
-	pt3 := points[2]
+	pt0 := controls[0]
+	pt1 := controls[1]
+	pt2 := controls[2] // Want to iterate until this is the last point (inclusive)
+	pt3 := controls[3]
 	tPrev := 0.0
-	tStart := math.Pow(Distance(pt0, pt1), alpha)
-	tEnd := tStart + math.Pow(Distance(pt1, pt2), alpha)
-	tNext := tEnd + math.Pow(Distance(pt2, pt3), alpha)
+	tStart := knotInterval(pt0, pt1, opts.Alpha)
+	tEnd := tStart + knotInterval(pt1, pt2, opts.Alpha)
+	tNext := tEnd + knotInterval(pt2, pt3, opts.Alpha)
 
-	for i := 0; i < nPoints-1; i++ {
+	for i := 0; i < nSegments; i++ {
 		var j uint = 0
 		for ; j < stepsAtRange[i]; j++ {
 			// Use Barry and Goldman's pyramid to interpolate
@@ -95,25 +147,100 @@ func CubicCatmullRomInterpolation(points []Float64Point, alpha float64, totSteps
 			C12 := Float64Point{float64(L012.X)*((tEnd-t)/(tEnd-tStart)) + float64(L123.X)*((t-tStart)/(tEnd-tStart)), float64(L012.Y)*((tEnd-t)/(tEnd-tStart)) + float64(L123.Y)*((t-tStart)/(tEnd-tStart))}
 			resultPts = append(resultPts, C12)
 		}
+		if i+4 >= len(controls) {
+			break
+		}
 		// Iterate over next set of points in curve
 		pt0 = pt1
 		pt1 = pt2
 		pt2 = pt3
-		if i+3 >= nPoints {
-			pt3 = postpendControl
-		} else {
-			pt3 = points[i+3]
-		}
+		pt3 = controls[i+4]
 		// Update t parameters for next iteration
 		tPrev = tStart
 		tStart = tEnd
 		tEnd = tNext
-		tNext = tNext + math.Pow(Distance(pt2, pt3), alpha)
+		tNext = tNext + knotInterval(pt2, pt3, opts.Alpha)
 	}
-	resultPts = append(resultPts, points[len(points)-1])
+	resultPts = append(resultPts, controls[nSegments+1])
 	return resultPts, nil
 }
 
+// AdaptiveCubicCatmullRomInterpolation computes the Catmull-Rom spline from a given
+// set of points like CubicCatmullRomInterpolationWith, but places the points
+// adaptively rather than spacing a fixed number of steps by chord length. Each
+// segment is subdivided until the polyline through the points lies within tolerance
+// of the curve, so bends are sampled densely and straight runs sparsely. The points
+// passed in are always part of the result.
+func AdaptiveCubicCatmullRomInterpolation(points []Float64Point, opts CatmullRomOptions, tolerance float64) ([]Float64Point, error) {
+	spline, err := NewCatmullRomSpline(points, opts)
+	if err != nil {
+		return nil, err
+	}
+	return AdaptiveSample(spline, tolerance)
+}
+
+// catmullRomControls surrounds three or more points with a control point on either
+// side, so every segment between controls[i+1] and controls[i+2] has a control
+// point before and after it. A closed curve gains a segment from the last point
+// back to the first.
+func catmullRomControls(points []Float64Point, opts CatmullRomOptions) ([]Float64Point, error) {
+	nPoints := len(points)
+	controls := make([]Float64Point, 0, nPoints+3)
+	if opts.Closed {
+		if nPoints < 3 {
+			return nil, errors.New("CubicCatmullRomInterpolation: A closed curve needs three or more points")
+		}
+		controls = append(controls, points[nPoints-1])
+		controls = append(controls, points...)
+		controls = append(controls, points[0], points[1])
+		return controls, nil
+	}
+	startControl, err := phantomControl(opts.Start, opts.StartPhantom, points[0], points[1], points[2])
+	if err != nil {
+		return nil, err
+	}
+	endControl, err := phantomControl(opts.End, opts.EndPhantom, points[nPoints-1], points[nPoints-2], points[nPoints-3])
+	if err != nil {
+		return nil, err
+	}
+	controls = append(controls, startControl)
+	controls = append(controls, points...)
+	controls = append(controls, endControl)
+	return controls, nil
+}
+
+// phantomControl computes the control point beyond the end point end, whose
+// neighbors along the line are next and after.
+func phantomControl(condition EndCondition, phantom *Float64Point, end, next, after Float64Point) (Float64Point, error) {
+	switch condition {
+	case EndExtrapolate:
+		return Float64Point{end.X - 2*(next.X-end.X), end.Y - 2*(next.Y-end.Y)}, nil
+	case EndReflect:
+		return Float64Point{2*end.X - next.X, 2*end.Y - next.Y}, nil
+	case EndDuplicate:
+		return end, nil
+	case EndNatural:
+		return Float64Point{(5*end.X - 4*next.X + after.X) / 2, (5*end.Y - 4*next.Y + after.Y) / 2}, nil
+	case EndPhantom:
+		if phantom == nil {
+			return Float64Point{}, errors.New("CubicCatmullRomInterpolation: EndPhantom requires a phantom point")
+		}
+		return *phantom, nil
+	}
+	return Float64Point{}, errors.New("CubicCatmullRomInterpolation: Unknown end condition")
+}
+
+// knotInterval is the parameter interval of a Catmull-Rom spline between two
+// control points. Coincident control points, as made by EndDuplicate, are given a
+// tiny interval so the spline remains defined.
+func knotInterval(p1, p2 Float64Point, alpha float64) float64 {
+	interval := math.Pow(Distance(p1, p2), alpha)
+	if interval == 0 {
+		return 1e-9
+	}
+	return interval
+}
+
 // Distance computes the distance between two floating-point points.
 func Distance(p1, p2 Float64Point) float64 {
 	return math.Pow(math.Pow(p1.X-p2.X, 2.0)+math.Pow(p1.Y-p2.Y, 2.0), 0.5)
@@ -123,3 +250,104 @@ func Distance(p1, p2 Float64Point) float64 {
 func DistanceImagePoint(p1, p2 image.Point) float64 {
 	return math.Pow(float64(p1.X*p1.X+p2.Y*p2.Y), 0.5)
 }
+
+// MonotoneCubicInterpolation computes a monotone piecewise cubic Hermite spline
+// through a given set of points using the Fritsch-Carlson method. If vertical is
+// true, x is interpolated as a function of y, otherwise y as a function of x, so the
+// points must strictly increase along that sweep axis. The resulting line is then
+// single-valued along the sweep axis, and does not overshoot wherever the points are
+// monotone across it. There must be at least two points passed in and the total
+// steps must be 2 or greater; the steps are spaced evenly along the sweep axis.
+func MonotoneCubicInterpolation(points []Float64Point, vertical bool, totSteps int) ([]Float64Point, error) {
+	if len(points) < 2 {
+		return nil, errors.New("MonotoneCubicInterpolation: Less than two points passed in")
+	}
+	if totSteps < 2 {
+		return nil, errors.New("MonotoneCubicInterpolation: Total steps must be 2 or greater")
+	}
+	curve, err := newMonotoneCubic(points, vertical)
+	if err != nil {
+		return nil, errors.New("MonotoneCubicInterpolation: " + err.Error())
+	}
+	first := curve.knots[0]
+	last := curve.knots[len(curve.knots)-1]
+	resultPts := make([]Float64Point, 0, totSteps)
+	for i := 0; i < totSteps-1; i++ {
+		s := first + (last-first)*float64(i)/float64(totSteps-1)
+		resultPts = append(resultPts, curve.point(s, vertical))
+	}
+	resultPts = append(resultPts, points[len(points)-1])
+	return resultPts, nil
+}
+
+// monotoneCubic is a piecewise cubic Hermite function of the sweep coordinate of a
+// line, with slopes limited by the Fritsch-Carlson conditions.
+type monotoneCubic struct {
+	knots  []float64
+	values []float64
+	slopes []float64
+}
+
+func newMonotoneCubic(points []Float64Point, vertical bool) (*monotoneCubic, error) {
+	n := len(points)
+	curve := &monotoneCubic{make([]float64, n), make([]float64, n), make([]float64, n)}
+	for i, pt := range points {
+		if vertical {
+			curve.knots[i], curve.values[i] = pt.Y, pt.X
+		} else {
+			curve.knots[i], curve.values[i] = pt.X, pt.Y
+		}
+		if i > 0 && curve.knots[i] <= curve.knots[i-1] {
+			return nil, errors.New("Point " + strconv.Itoa(i) + " does not strictly increase along the sweep axis")
+		}
+	}
+	secants := make([]float64, n-1)
+	for i := 0; i < n-1; i++ {
+		secants[i] = (curve.values[i+1] - curve.values[i]) / (curve.knots[i+1] - curve.knots[i])
+	}
+	curve.slopes[0] = secants[0]
+	curve.slopes[n-1] = secants[n-2]
+	for i := 1; i < n-1; i++ {
+		if secants[i-1]*secants[i] > 0 {
+			curve.slopes[i] = (secants[i-1] + secants[i]) / 2
+		}
+	}
+	for i := 0; i < n-1; i++ {
+		if secants[i] == 0 {
+			curve.slopes[i] = 0
+			curve.slopes[i+1] = 0
+			continue
+		}
+		a := curve.slopes[i] / secants[i]
+		b := curve.slopes[i+1] / secants[i]
+		if sumSq := a*a + b*b; sumSq > 9 {
+			tau := 3 / math.Sqrt(sumSq)
+			curve.slopes[i] = tau * a * secants[i]
+			curve.slopes[i+1] = tau * b * secants[i]
+		}
+	}
+	return curve, nil
+}
+
+// at evaluates the function at the sweep coordinate s, clamped to the knots.
+func (c *monotoneCubic) at(s float64) float64 {
+	n := len(c.knots)
+	if s <= c.knots[0] {
+		return c.values[0]
+	} else if s >= c.knots[n-1] {
+		return c.values[n-1]
+	}
+	i := sort.SearchFloat64s(c.knots, s) - 1
+	h := c.knots[i+1] - c.knots[i]
+	u := (s - c.knots[i]) / h
+	u2 := u * u
+	u3 := u2 * u
+	return (2*u3-3*u2+1)*c.values[i] + (u3-2*u2+u)*h*c.slopes[i] + (-2*u3+3*u2)*c.values[i+1] + (u3-u2)*h*c.slopes[i+1]
+}
+
+func (c *monotoneCubic) point(s float64, vertical bool) Float64Point {
+	if vertical {
+		return Float64Point{c.at(s), s}
+	}
+	return Float64Point{s, c.at(s)}
+}
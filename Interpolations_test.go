@@ -1,6 +1,7 @@
 package gorph
 
 import (
+	"math"
 	"testing"
 )
 
@@ -71,3 +72,119 @@ func TestCentripetalCubicCatmullRomInterpolationFourPoints(t *testing.T) {
 	AssertEqualsFloat64PointTolerance(t, pts[30], Float64Point{2, 1}, .000001, "Point 30 incorrect")
 	LogVerbose(t, pts)
 }
+
+func TestMonotoneCubicInterpolation(t *testing.T) {
+	points := []Float64Point{{0, 0}, {50, 5}, {0, 6}, {0, 40}}
+	result, err := MonotoneCubicInterpolation(points, true, 81)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(result), 81, "Incorrect number of points")
+	AssertEqualsFloat64Point(t, result[0], points[0], "Incorrect first point")
+	AssertEqualsFloat64Point(t, result[80], points[3], "Incorrect last point")
+	for i := 1; i < len(result); i++ {
+		if result[i].Y <= result[i-1].Y {
+			t.Fatal("Line is not single-valued in y at", i, result[i-1], result[i])
+		}
+		if result[i].X < 0 || result[i].X > 50 {
+			t.Error("Line overshoots its points at", i, result[i])
+		}
+	}
+	// Samples are evenly spaced in y, so y=5 falls exactly on the second point.
+	AssertEqualsFloat64PointTolerance(t, result[10], points[1], .000001, "Curve does not pass through point")
+}
+
+func TestMonotoneCubicInterpolationHorizontal(t *testing.T) {
+	points := []Float64Point{{0, 0}, {1, 1}, {2, 1}, {3, 4}}
+	result, err := MonotoneCubicInterpolation(points, false, 31)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for i := 1; i < len(result); i++ {
+		if result[i].Y < result[i-1].Y {
+			t.Error("Monotone data produced a decreasing curve at", i, result[i-1], result[i])
+		}
+	}
+	for i := 10; i <= 20; i++ {
+		AssertEqualsFloat64PointTolerance(t, Float64Point{0, result[i].Y}, Float64Point{0, 1}, .000001, "Flat segment is not flat")
+	}
+}
+
+func TestMonotoneCubicInterpolationUnordered(t *testing.T) {
+	_, err := MonotoneCubicInterpolation([]Float64Point{{0, 0}, {0, 10}, {0, 5}}, true, 10)
+	if err == nil {
+		t.Error("Expected error for points out of order")
+	}
+}
+
+func TestCubicCatmullRomInterpolationWithDefaults(t *testing.T) {
+	points := []Float64Point{{0, 0}, {1, 1}, {3, 0}, {4, 2}}
+	expected, err := CubicCatmullRomInterpolation(points, 0.5, 40)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	phantom := Float64Point{6, 6}
+	result, err := CubicCatmullRomInterpolationWith(points, CatmullRomOptions{Alpha: 0.5, End: EndPhantom, EndPhantom: &phantom}, 40)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(result), len(expected), "Interpolation step length differs")
+	for i := range expected {
+		AssertEqualsFloat64PointTolerance(t, result[i], expected[i], .000001, "Supplying the extrapolated phantom point changed the curve")
+	}
+	_, err = CubicCatmullRomInterpolationWith(points, CatmullRomOptions{Alpha: 0.5, Start: EndPhantom}, 40)
+	if err == nil {
+		t.Error("Expected error for missing phantom point")
+	}
+}
+
+func TestCubicCatmullRomInterpolationEndConditions(t *testing.T) {
+	points := []Float64Point{{0, 0}, {1, 1}, {2, 0}, {3, 1}}
+	for _, condition := range []EndCondition{EndExtrapolate, EndReflect, EndDuplicate, EndNatural} {
+		result, err := CubicCatmullRomInterpolationWith(points, CatmullRomOptions{Alpha: 0.5, Start: condition, End: condition}, 60)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		AssertEqualsFloat64PointTolerance(t, result[0], points[0], .000001, "First point incorrect")
+		AssertEqualsFloat64PointTolerance(t, result[len(result)-1], points[3], .000001, "Last point incorrect")
+		for i, pt := range result {
+			if math.IsNaN(pt.X) || math.IsNaN(pt.Y) {
+				t.Fatal("End condition", condition, "produced NaN at", i)
+			}
+		}
+	}
+}
+
+func TestCubicCatmullRomInterpolationNaturalEnd(t *testing.T) {
+	points := []Float64Point{{0, 0}, {1, 2}, {3, 0}}
+	secondDifference := func(condition EndCondition) float64 {
+		result, err := CubicCatmullRomInterpolationWith(points, CatmullRomOptions{Alpha: 0, Start: condition}, 200)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return Distance(Float64Point{result[2].X - 2*result[1].X + result[0].X, result[2].Y - 2*result[1].Y + result[0].Y}, Float64Point{0, 0})
+	}
+	if natural, extrapolated := secondDifference(EndNatural), secondDifference(EndExtrapolate); natural > extrapolated/10 {
+		t.Error("Natural end is not flat", natural, extrapolated)
+	}
+}
+
+func TestCubicCatmullRomInterpolationClosed(t *testing.T) {
+	points := []Float64Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}
+	result, err := CubicCatmullRomInterpolationWith(points, CatmullRomOptions{Alpha: 0.5, Closed: true}, 80)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(result), 81, "Interpolation step length failed")
+	AssertEqualsFloat64PointTolerance(t, result[0], points[0], .000001, "Closed curve does not start at the first point")
+	AssertEqualsFloat64PointTolerance(t, result[80], points[0], .000001, "Closed curve does not end at the first point")
+	for i, pt := range points {
+		AssertEqualsFloat64PointTolerance(t, result[i*20], pt, .000001, "Closed curve does not pass through point")
+	}
+	// The curve is symmetric, so it passes through the closing segment like the others.
+	AssertEqualsFloat64PointTolerance(t, Float64Point{result[70].X, 0}, Float64Point{result[10].Y, 0}, .000001, "Closing segment is not symmetric")
+	_, err = CubicCatmullRomInterpolationWith(points[:2], CatmullRomOptions{Closed: true}, 10)
+	if err == nil {
+		t.Error("Expected error for closed curve of two points")
+	}
+}
@@ -0,0 +1,197 @@
+package gorph
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LandmarkError describes a problem found while reading a landmark file, along with
+// the line of the file it was found on.
+type LandmarkError struct {
+	Line    int
+	Message string
+}
+
+// Error implements the error interface.
+func (e *LandmarkError) Error() string {
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Message
+}
+
+// LandmarkPair holds homogulous landmarks on a start and destination image. A list
+// of pairs is a scattered correspondence between the two images, as opposed to the
+// structured correspondence of a MorphGrid, and is warped by a ThinPlateSpline.
+type LandmarkPair struct {
+	Start Float64Point
+	Dest  Float64Point
+}
+
+// ReadPTS reads landmarks in the IBUG .pts format, as used for the 68 point face
+// annotations of dlib and the 300-W dataset:
+//
+//	version: 1
+//	n_points: 68
+//	{
+//	x y
+//	...
+//	}
+//
+// Errors are of type *LandmarkError.
+func ReadPTS(r io.Reader) ([]Float64Point, error) {
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	nPoints := -1
+	inPoints := false
+	closed := false
+	var pts []Float64Point
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if closed {
+			return nil, &LandmarkError{lineNum, "unexpected content after closing brace"}
+		}
+		if !inPoints {
+			if line == "{" {
+				if nPoints < 0 {
+					return nil, &LandmarkError{lineNum, "missing n_points header"}
+				}
+				inPoints = true
+				continue
+			}
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				return nil, &LandmarkError{lineNum, "expected a \"key: value\" header or an opening brace"}
+			}
+			if strings.TrimSpace(key) == "n_points" {
+				n, err := strconv.Atoi(strings.TrimSpace(value))
+				if err != nil || n < 0 {
+					return nil, &LandmarkError{lineNum, "malformed n_points \"" + strings.TrimSpace(value) + "\""}
+				}
+				nPoints = n
+			}
+			continue
+		}
+		if line == "}" {
+			if len(pts) != nPoints {
+				return nil, &LandmarkError{lineNum, "expected " + strconv.Itoa(nPoints) + " points, found " + strconv.Itoa(len(pts))}
+			}
+			closed = true
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, &LandmarkError{lineNum, "expected two coordinates, found " + strconv.Itoa(len(fields))}
+		}
+		pt, err := parseLandmark(fields[0], fields[1])
+		if err != nil {
+			return nil, &LandmarkError{lineNum, err.Error()}
+		}
+		pts = append(pts, pt)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &LandmarkError{lineNum, err.Error()}
+	}
+	if !closed {
+		return nil, &LandmarkError{lineNum, "missing closing brace"}
+	}
+	return pts, nil
+}
+
+// ReadLandmarkCSV reads landmarks from a CSV list with one landmark per record. A
+// record holds either the "x,y" coordinates, or a label or index followed by the
+// coordinates, which is ignored. A first record whose coordinates are not numeric
+// is treated as a header. Errors are of type *LandmarkError.
+func ReadLandmarkCSV(r io.Reader) ([]Float64Point, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var pts []Float64Point
+	for record := 0; ; record++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, &LandmarkError{parseErr.Line, parseErr.Err.Error()}
+			}
+			return nil, err
+		}
+		lineNum, _ := reader.FieldPos(0)
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, &LandmarkError{lineNum, "expected 2 or 3 fields, found " + strconv.Itoa(len(fields))}
+		}
+		pt, err := parseLandmark(fields[len(fields)-2], fields[len(fields)-1])
+		if err != nil {
+			if record == 0 {
+				continue
+			}
+			return nil, &LandmarkError{lineNum, err.Error()}
+		}
+		pts = append(pts, pt)
+	}
+	return pts, nil
+}
+
+func parseLandmark(xField, yField string) (Float64Point, error) {
+	x, err := strconv.ParseFloat(strings.TrimSpace(xField), 64)
+	if err != nil {
+		return Float64Point{}, errors.New("malformed x coordinate \"" + xField + "\"")
+	}
+	y, err := strconv.ParseFloat(strings.TrimSpace(yField), 64)
+	if err != nil {
+		return Float64Point{}, errors.New("malformed y coordinate \"" + yField + "\"")
+	}
+	return Float64Point{x, y}, nil
+}
+
+// PairLandmarks pairs the landmarks of a start and destination image by their
+// position in each list. Returns an error if the lists differ in length.
+func PairLandmarks(start, dest []Float64Point) ([]LandmarkPair, error) {
+	if len(start) != len(dest) {
+		return nil, errors.New("PairLandmarks: Start has " + strconv.Itoa(len(start)) + " landmarks but dest has " + strconv.Itoa(len(dest)))
+	}
+	pairs := make([]LandmarkPair, len(start))
+	for i := range start {
+		pairs[i] = LandmarkPair{start[i], dest[i]}
+	}
+	return pairs, nil
+}
+
+// LandmarksToMorphGrid places the landmarks of a start and destination image onto a
+// MorphGrid. The mapping gives the grid intersection of every landmark index that is
+// to be used; landmarks missing from the mapping are left out. Returns an error if
+// the lists differ in length, a mapped index is out of range, or two landmarks are
+// mapped onto the same intersection.
+func LandmarksToMorphGrid(start, dest []Float64Point, mapping map[int]GridIndex) (*MorphGrid, error) {
+	pairs, err := PairLandmarks(start, dest)
+	if err != nil {
+		return nil, errors.New("LandmarksToMorphGrid: " + err.Error())
+	}
+	used := make(map[GridIndex]int)
+	for landmark, index := range mapping {
+		if landmark < 0 || landmark >= len(pairs) {
+			return nil, errors.New("LandmarksToMorphGrid: Landmark " + strconv.Itoa(landmark) + " is out of range")
+		}
+		if index.HorizLine < 0 || index.VertLine < 0 {
+			return nil, errors.New("LandmarksToMorphGrid: Landmark " + strconv.Itoa(landmark) + " is mapped onto a negative line index")
+		}
+		if other, ok := used[index]; ok {
+			return nil, errors.New("LandmarksToMorphGrid: Landmarks " + strconv.Itoa(other) + " and " + strconv.Itoa(landmark) + " are mapped onto the same intersection")
+		}
+		used[index] = landmark
+	}
+	mGrid := NewMorphGrid()
+	for landmark := range pairs {
+		if index, ok := mapping[landmark]; ok {
+			mGrid.AddFloat64Points(index.HorizLine, index.VertLine, pairs[landmark].Start, pairs[landmark].Dest)
+		}
+	}
+	return mGrid, nil
+}
@@ -0,0 +1,83 @@
+package gorph
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadPTS(t *testing.T) {
+	doc := "version: 1\nn_points:  3\n{\n1.5 2\n3 4.25\r\n5 6\n}\n"
+	pts, err := ReadPTS(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(pts), 3, "Point count incorrect")
+	AssertEqualsFloat64Point(t, pts[0], Float64Point{1.5, 2})
+	AssertEqualsFloat64Point(t, pts[1], Float64Point{3, 4.25})
+}
+
+func TestReadPTSErrors(t *testing.T) {
+	docs := map[string]int{
+		"version: 1\nn_points: 2\n{\n1 2\n3 x\n}\n": 5,
+		"version: 1\nn_points: 3\n{\n1 2\n3 4\n}\n": 6,
+		"version: 1\n{\n1 2\n}\n":                   2,
+		"version: 1\nn_points: 1\n{\n1 2\n":         4,
+		"version: 1\nn_points: 1\n{\n1 2 3\n}\n":    4,
+		"version: 1\nn_points: one\n{\n1 2 3\n}\n":  2,
+		"version: 1\nn_points: 1\n{\n1 2\n}\n3 4\n": 6,
+	}
+	for doc, line := range docs {
+		_, err := ReadPTS(strings.NewReader(doc))
+		var landmarkErr *LandmarkError
+		if !errors.As(err, &landmarkErr) {
+			t.Error("Expected a LandmarkError for", doc)
+			continue
+		}
+		AssertEqualsInt(t, landmarkErr.Line, line, landmarkErr.Error())
+	}
+}
+
+func TestReadLandmarkCSV(t *testing.T) {
+	doc := "name,x,y\nnose, 1.5, 2\nchin,3,4\n"
+	pts, err := ReadLandmarkCSV(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(pts), 2, "Point count incorrect")
+	AssertEqualsFloat64Point(t, pts[0], Float64Point{1.5, 2})
+	AssertEqualsFloat64Point(t, pts[1], Float64Point{3, 4})
+}
+
+func TestReadLandmarkCSVError(t *testing.T) {
+	_, err := ReadLandmarkCSV(strings.NewReader("1,2\n3,4\n5,y\n"))
+	var landmarkErr *LandmarkError
+	if !errors.As(err, &landmarkErr) {
+		t.Fatal("Expected a LandmarkError")
+	}
+	AssertEqualsInt(t, landmarkErr.Line, 3)
+}
+
+func TestLandmarksToMorphGrid(t *testing.T) {
+	start := []Float64Point{{0, 0}, {1, 1}, {2, 2}}
+	dest := []Float64Point{{0, 1}, {1, 2}, {2, 3}}
+	mGrid, err := LandmarksToMorphGrid(start, dest, map[int]GridIndex{0: {0, 0}, 2: {1, 1}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(mGrid.Intersections()), 2, "Intersection count incorrect")
+	source, destPt, err := mGrid.Float64Points(1, 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64Point(t, source, Float64Point{2, 2})
+	AssertEqualsFloat64Point(t, destPt, Float64Point{2, 3})
+	_, err = LandmarksToMorphGrid(start, dest, map[int]GridIndex{0: {0, 0}, 1: {0, 0}})
+	if err == nil {
+		t.Error("Expected error for landmarks mapped onto the same intersection")
+	}
+	_, err = LandmarksToMorphGrid(start, dest[:2], map[int]GridIndex{0: {0, 0}})
+	if err == nil {
+		t.Error("Expected error for mismatched landmark counts")
+	}
+}
@@ -0,0 +1,212 @@
+package gorph
+
+import (
+	"errors"
+)
+
+// LineModel selects the kind of curve drawn through the points of each grid line.
+type LineModel int
+
+const (
+	// LineCatmullRom draws centripetal Catmull-Rom splines through the points of
+	// each line. It is the default.
+	LineCatmullRom LineModel = iota
+	// LineMonotoneCubic draws monotone cubic splines through the points of each line
+	// with MonotoneCubicInterpolation. A vertical line is then always a function of
+	// y, and a horizontal line a function of x, as long as the points along it are
+	// in order.
+	LineMonotoneCubic
+)
+
+var lineModelNames = map[LineModel]string{
+	LineCatmullRom:    "catmull-rom",
+	LineMonotoneCubic: "monotone-cubic",
+}
+
+// String returns the name of the line model.
+func (model LineModel) String() string {
+	if name, ok := lineModelNames[model]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// LineModelByName looks up a line model by its name, "catmull-rom" or
+// "monotone-cubic"; an empty name is Catmull-Rom.
+func LineModelByName(name string) (LineModel, error) {
+	if name == "" {
+		return LineCatmullRom, nil
+	}
+	for model, modelName := range lineModelNames {
+		if modelName == name {
+			return model, nil
+		}
+	}
+	return LineCatmullRom, errors.New("LineModelByName: Unknown line model \"" + name + "\"")
+}
+
+// endConditionNames names the end conditions of grid lines in the encodings of a
+// MorphGrid.
+var endConditionNames = map[EndCondition]string{
+	EndExtrapolate: "extrapolate",
+	EndReflect:     "reflect",
+	EndDuplicate:   "duplicate",
+	EndNatural:     "natural",
+	EndPhantom:     "phantom",
+}
+
+// endConditionByName looks up an end condition by its name; an empty name is
+// EndExtrapolate.
+func endConditionByName(name string) (EndCondition, error) {
+	if name == "" {
+		return EndExtrapolate, nil
+	}
+	for condition, conditionName := range endConditionNames {
+		if conditionName == name {
+			return condition, nil
+		}
+	}
+	return EndExtrapolate, errors.New("Unknown end condition \"" + name + "\"")
+}
+
+// LineFitter builds the curve drawn through the points of a grid line. The points
+// of a vertical line are ordered by increasing y and those of a horizontal line by
+// increasing x, as are the lines of a well formed grid.
+type LineFitter interface {
+	Fit(points []Float64Point, vertical bool) (*CubicSpline, error)
+}
+
+// LineFitterFunc adapts a function to the LineFitter interface, such as one building
+// a Hermite spline with tangents of its own choosing.
+type LineFitterFunc func(points []Float64Point, vertical bool) (*CubicSpline, error)
+
+// Fit calls the function.
+func (f LineFitterFunc) Fit(points []Float64Point, vertical bool) (*CubicSpline, error) {
+	return f(points, vertical)
+}
+
+// CatmullRomFitter fits Catmull-Rom splines with NewCatmullRomSpline.
+type CatmullRomFitter struct {
+	Alpha float64
+	Start EndCondition
+	End   EndCondition
+}
+
+// Fit builds the Catmull-Rom spline through the points.
+func (f CatmullRomFitter) Fit(points []Float64Point, vertical bool) (*CubicSpline, error) {
+	return NewCatmullRomSpline(points, CatmullRomOptions{Alpha: f.Alpha, Start: f.Start, End: f.End})
+}
+
+// MonotoneCubicFitter fits monotone cubic splines with NewMonotoneCubicSpline.
+type MonotoneCubicFitter struct{}
+
+// Fit builds the monotone cubic spline through the points.
+func (f MonotoneCubicFitter) Fit(points []Float64Point, vertical bool) (*CubicSpline, error) {
+	return NewMonotoneCubicSpline(points, vertical)
+}
+
+// BSplineFitter fits uniform cubic B-splines with NewBSpline, using the points as
+// control points. The end points are repeated three times so the line still starts
+// and ends at them, but it only approaches the points in between.
+type BSplineFitter struct{}
+
+// Fit builds the B-spline of the points.
+func (f BSplineFitter) Fit(points []Float64Point, vertical bool) (*CubicSpline, error) {
+	if len(points) < 2 {
+		return nil, errors.New("Fit: Less than two points passed in")
+	}
+	first, last := points[0], points[len(points)-1]
+	controls := make([]Float64Point, 0, len(points)+4)
+	controls = append(controls, first, first)
+	controls = append(controls, points...)
+	controls = append(controls, last, last)
+	return NewBSpline(controls)
+}
+
+// KochanekBartelsFitter fits Kochanek-Bartels splines with NewKochanekBartelsSpline.
+type KochanekBartelsFitter struct {
+	KochanekBartels
+}
+
+// Fit builds the Kochanek-Bartels spline through the points.
+func (f KochanekBartelsFitter) Fit(points []Float64Point, vertical bool) (*CubicSpline, error) {
+	return NewKochanekBartelsSpline(points, f.KochanekBartels)
+}
+
+// lineStyle gathers the settings of a MorphGrid that shape the curves drawn
+// through the points of its lines. A fitter takes precedence over the line model
+// and end conditions.
+type lineStyle struct {
+	model    LineModel
+	startEnd EndCondition
+	endEnd   EndCondition
+	fitter   LineFitter
+}
+
+// interpolate samples the curve through the points of a line.
+func (style lineStyle) interpolate(points []Float64Point, vertical bool, totSteps int) ([]Float64Point, error) {
+	if style.fitter != nil {
+		spline, err := style.fitter.Fit(points, vertical)
+		if err != nil {
+			return nil, err
+		}
+		return spline.Sample(totSteps)
+	}
+	switch style.model {
+	case LineMonotoneCubic:
+		return MonotoneCubicInterpolation(points, vertical, totSteps)
+	case LineCatmullRom:
+		return CubicCatmullRomInterpolationWith(points, CatmullRomOptions{Alpha: 0.5, Start: style.startEnd, End: style.endEnd}, totSteps)
+	}
+	return nil, errors.New("interpolate: Unknown line model")
+}
+
+// lineFitter returns the fitter of the style, or else that of its line model.
+func (style lineStyle) lineFitter() (LineFitter, error) {
+	if style.fitter != nil {
+		return style.fitter, nil
+	}
+	switch style.model {
+	case LineMonotoneCubic:
+		return MonotoneCubicFitter{}, nil
+	case LineCatmullRom:
+		return CatmullRomFitter{0.5, style.startEnd, style.endEnd}, nil
+	}
+	return nil, errors.New("lineFitter: Unknown line model")
+}
+
+// encodedFitter returns the fitter of the style for the encodings of a MorphGrid,
+// dereferencing pointers to the fitters of this package. Returns an error for any
+// other LineFitter, such as a LineFitterFunc, whose curves cannot be recorded.
+func (style lineStyle) encodedFitter() (LineFitter, error) {
+	switch f := style.fitter.(type) {
+	case nil, CatmullRomFitter, MonotoneCubicFitter, BSplineFitter, KochanekBartelsFitter:
+		return f, nil
+	case *CatmullRomFitter:
+		if f != nil {
+			return *f, nil
+		}
+	case *MonotoneCubicFitter:
+		if f != nil {
+			return *f, nil
+		}
+	case *BSplineFitter:
+		if f != nil {
+			return *f, nil
+		}
+	case *KochanekBartelsFitter:
+		if f != nil {
+			return *f, nil
+		}
+	}
+	return nil, errors.New("Custom line fitters cannot be encoded")
+}
+
+// spline computes the analytic curve through the points of a line.
+func (style lineStyle) spline(points []Float64Point, vertical bool) (*CubicSpline, error) {
+	fitter, err := style.lineFitter()
+	if err != nil {
+		return nil, err
+	}
+	return fitter.Fit(points, vertical)
+}
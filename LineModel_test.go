@@ -0,0 +1,37 @@
+package gorph
+
+import (
+	"image"
+	"testing"
+)
+
+func TestLineModelByName(t *testing.T) {
+	for _, model := range []LineModel{LineCatmullRom, LineMonotoneCubic} {
+		result, err := LineModelByName(model.String())
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		AssertEqualsInt(t, int(result), int(model), "Wrong line model for "+model.String())
+	}
+	if model, err := LineModelByName(""); err != nil || model != LineCatmullRom {
+		t.Error("Empty name should be Catmull-Rom")
+	}
+	if _, err := LineModelByName("bezier"); err == nil {
+		t.Error("Expected error for unknown line model")
+	}
+}
+
+func TestLineModelValidate(t *testing.T) {
+	mGrid := NewMorphGrid()
+	pts := []image.Point{{0, 0}, {50, 5}, {0, 6}, {0, 40}}
+	for h, pt := range pts {
+		mGrid.AddPoints(h, 0, pt, pt)
+	}
+	if len(foldProblems(mGrid.Validate())) == 0 {
+		t.Error("Catmull-Rom line should fold")
+	}
+	mGrid.SetLineModel(LineMonotoneCubic)
+	AssertEqualsInt(t, len(foldProblems(mGrid.Validate())), 0, "Monotone cubic line should not fold")
+	rescaled := mGrid.mapped(func(pt Float64Point) Float64Point { return pt }, func(pt Float64Point) Float64Point { return pt })
+	AssertEqualsInt(t, int(rescaled.LineModel()), int(LineMonotoneCubic), "Copy lost its line model")
+}
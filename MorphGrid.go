@@ -1,29 +1,143 @@
 package gorph
 
 import (
+	"errors"
 	"image"
 )
 
+// maxDecodedLineIndex is the largest line index accepted when decoding a MorphGrid.
+// Adding a point allocates every line up to its indices, so a larger index read
+// from a damaged or hostile file could exhaust memory.
+const maxDecodedLineIndex = 1 << 14
+
 // MorphGrid is a metadata structure usually used alongside images for specifying
 // parameters used in transformations. The MorphGrid consists of two grids in order
 // to hold data for a pre-morph and post-morph state. It manages these pairs of
 // points so accompanying morphing algorithms can easily use the parametric information
 // between homogulous lines. For details on how a MorphGrid is exactly used, please
 // refer to a morphing algorithm's documentation.
+//
+// A MorphGrid may optionally carry reference bounds, which are the bounds of the
+// images its points were authored against. Such a grid is independent of the
+// resolution of the images it is eventually used with, as it may be rescaled to
+// any other bounds.
 type MorphGrid struct {
-	start *coordinateGrid
-	dest  *coordinateGrid
+	start     *float64CoordinateGrid
+	dest      *float64CoordinateGrid
+	reference image.Rectangle
+	style     lineStyle
+}
+
+// GridIndex identifies the intersection of a horizontal and a vertical grid line.
+type GridIndex struct {
+	HorizLine int
+	VertLine  int
 }
 
 // NewMorphGrid supplies a new instance of a MorphGrid.
 func NewMorphGrid() *MorphGrid {
-	return &MorphGrid{newCoordinateGrid(), newCoordinateGrid()}
+	return &MorphGrid{newFloat64CoordinateGrid(), newFloat64CoordinateGrid(), image.ZR, lineStyle{}}
+}
+
+// NewNormalizedMorphGrid supplies a new instance of a MorphGrid whose points are in
+// normalized coordinates, where the range [0.0, 1.0] spans the width and height of
+// an image. Its reference bounds are image.Rect(0, 0, 1, 1), so its points must be
+// added with AddFloat64Points.
+func NewNormalizedMorphGrid() *MorphGrid {
+	return &MorphGrid{newFloat64CoordinateGrid(), newFloat64CoordinateGrid(), image.Rect(0, 0, 1, 1), lineStyle{}}
+}
+
+// SetReferenceBounds attaches the bounds of the images the grid's points are expressed
+// against, such as those of a low resolution proxy the grid was authored on. Bounds
+// of image.Rect(0, 0, 1, 1) denote normalized coordinates. Passing empty bounds
+// removes the reference bounds.
+func (m *MorphGrid) SetReferenceBounds(bounds image.Rectangle) {
+	m.reference = bounds
+}
+
+// ReferenceBounds returns the reference bounds attached to the grid, and whether any
+// have been attached.
+func (m *MorphGrid) ReferenceBounds() (image.Rectangle, bool) {
+	return m.reference, !m.reference.Empty()
+}
+
+// SetLineModel selects the kind of curve drawn through the points of each grid line
+// when the grid is used to morph. The default is LineCatmullRom.
+func (m *MorphGrid) SetLineModel(model LineModel) {
+	m.style.model = model
+}
+
+// LineModel returns the kind of curve drawn through the points of each grid line.
+func (m *MorphGrid) LineModel() LineModel {
+	return m.style.model
+}
+
+// SetLineEnds selects the end conditions of the Catmull-Rom splines drawn through
+// the grid lines at their first and last points, such as EndReflect for lines
+// pinned to the edges of the image. EndPhantom is not supported for grid lines.
+// The default is EndExtrapolate at both ends.
+func (m *MorphGrid) SetLineEnds(start, end EndCondition) {
+	m.style.startEnd = start
+	m.style.endEnd = end
+}
+
+// SetLineFitter selects the LineFitter that builds the curve drawn through the
+// points of each grid line, such as a KochanekBartelsFitter. It takes precedence
+// over the line model and line ends. Passing nil restores them. The fitters of this
+// package are kept by the JSON and binary encodings of the grid, which return an
+// error for any other fitter, such as a LineFitterFunc.
+func (m *MorphGrid) SetLineFitter(fitter LineFitter) {
+	m.style.fitter = fitter
+}
+
+// LineFitter returns the LineFitter that builds the grid lines. If none was set,
+// it returns the fitter of the grid's line model and line ends.
+func (m *MorphGrid) LineFitter() LineFitter {
+	fitter, err := m.style.lineFitter()
+	if err != nil {
+		return nil
+	}
+	return fitter
+}
+
+// LineEnds returns the end conditions of the Catmull-Rom splines drawn through the
+// grid lines.
+func (m *MorphGrid) LineEnds() (start, end EndCondition) {
+	return m.style.startEnd, m.style.endEnd
+}
+
+// Rescale creates a copy of the grid whose points are scaled from the grid's
+// reference bounds to the given bounds, which become the reference bounds of the copy.
+// Each axis is scaled independently. Returns an error if the grid has no reference
+// bounds or the given bounds are empty.
+func (m *MorphGrid) Rescale(bounds image.Rectangle) (*MorphGrid, error) {
+	reference, ok := m.ReferenceBounds()
+	if !ok {
+		return nil, errors.New("Rescale: MorphGrid has no reference bounds")
+	}
+	if bounds.Empty() {
+		return nil, errors.New("Rescale: Bounds must not be empty")
+	}
+	mapping := newFitMapping(reference, bounds, FitStretch)
+	result := m.mapped(mapping.apply, mapping.apply)
+	result.reference = bounds
+	return result, nil
 }
 
 // AddPoints adds two homogulous points for a before and after image on the
 // specified horizontal and vertical line indices. Replaces any preexisting
 // points.
 func (m *MorphGrid) AddPoints(horizLine, vertLine int, startPt, destPt image.Point) {
+	m.AddFloat64Points(horizLine, vertLine, ToFloat64Point(startPt), ToFloat64Point(destPt))
+}
+
+// AddFloat64Points adds two homogulous points with sub-pixel precision for a before
+// and after image on the specified horizontal and vertical line indices. Replaces
+// any preexisting points.
+func (m *MorphGrid) AddFloat64Points(horizLine, vertLine int, startPt, destPt Float64Point) {
+	if _, _, err := m.Float64Points(horizLine, vertLine); err == nil {
+		_ = m.RemovePoints(horizLine, vertLine)
+	}
 	m.start.addPoint(horizLine, vertLine, startPt)
 	m.dest.addPoint(horizLine, vertLine, destPt)
 }
@@ -53,102 +167,130 @@ func (m *MorphGrid) HorizontalGridlineCount() int {
 }
 
 // Points returns the homogulous pair of points at the intersection of the two
-// lines given by their indices.
+// lines given by their indices. Points with sub-pixel precision are rounded to
+// the nearest image.Point.
 func (m *MorphGrid) Points(horizLine, vertLine int) (image.Point, image.Point, error) {
+	startPt, endPt, err := m.Float64Points(horizLine, vertLine)
+	return roundFloat64Point(startPt), roundFloat64Point(endPt), err
+}
+
+// Float64Points returns the homogulous pair of points at the intersection of the
+// two lines given by their indices, with sub-pixel precision.
+func (m *MorphGrid) Float64Points(horizLine, vertLine int) (Float64Point, Float64Point, error) {
 	startPt, err := m.start.point(horizLine, vertLine)
 	if err != nil {
-		return startPt, image.ZP, err
+		return startPt, Float64Point{0, 0}, err
 	}
 	endPt, err := m.dest.point(horizLine, vertLine)
 	return startPt, endPt, err
 }
 
+// Intersections returns the indices of every intersection that holds a pair of
+// homogulous points. The indices are sorted by horizontal line, then by vertical
+// line.
+func (m *MorphGrid) Intersections() []GridIndex {
+	return m.start.intersections()
+}
+
 // HorizontalLine takes an index of a horizontal line and returns all points
 // associated with the line in both grids. The points are sorted in increasing
 // x-values.
 func (m *MorphGrid) HorizontalLine(index int) (source, dest []image.Point) {
-	source = make([]image.Point, 0, m.start.verticalGridlineLen())
-	dest = make([]image.Point, 0, m.start.verticalGridlineLen())
-	for vLine := 0; vLine < m.start.verticalGridlineLen(); vLine++ {
-		temp, err := m.start.point(index, vLine)
-		if err == nil {
-			source = append(source, temp)
-		}
-		temp, err = m.dest.point(index, vLine)
-		if err == nil {
-			dest = append(dest, temp)
-		}
-	}
-	return
+	floatSource, floatDest := m.HorizontalLineFloat64(index)
+	return roundFloat64Points(floatSource), roundFloat64Points(floatDest)
+}
+
+// HorizontalLineFloat64 takes an index of a horizontal line and returns all points
+// associated with the line in both grids with sub-pixel precision. The points are
+// sorted in increasing x-values.
+func (m *MorphGrid) HorizontalLineFloat64(index int) (source, dest []Float64Point) {
+	return m.start.horizontalLine(index), m.dest.horizontalLine(index)
 }
 
 // VerticalLine takes an index of a vertical line and returns all points
 // associated with the line in both grids. The points are sorted in increasing
 // y-values.
 func (m *MorphGrid) VerticalLine(index int) (source, dest []image.Point) {
-	source = make([]image.Point, 0, m.start.horizontalGridlineLen())
-	dest = make([]image.Point, 0, m.start.horizontalGridlineLen())
-	for hLine := 0; hLine < m.start.horizontalGridlineLen(); hLine++ {
-		temp, err := m.start.point(hLine, index)
-		if err == nil {
-			source = append(source, temp)
-		}
-		temp, err = m.dest.point(hLine, index)
-		if err == nil {
-			dest = append(dest, temp)
-		}
+	floatSource, floatDest := m.VerticalLineFloat64(index)
+	return roundFloat64Points(floatSource), roundFloat64Points(floatDest)
+}
+
+// VerticalLineFloat64 takes an index of a vertical line and returns all points
+// associated with the line in both grids with sub-pixel precision. The points are
+// sorted in increasing y-values.
+func (m *MorphGrid) VerticalLineFloat64(index int) (source, dest []Float64Point) {
+	return m.start.verticalLine(index), m.dest.verticalLine(index)
+}
+
+// CheckCrossings interpolates the grid at the given time fraction and verifies that
+// none of its grid lines cross each other. Points along each vertical line must
+// strictly increase in y-value, and points along each horizontal line must strictly
+// increase in x-value. This is useful to validate a time fraction outside of
+// [0.0, 1.0], where extrapolation can fold the grid. Returns an error describing the
+// first crossing found.
+//
+// The grid is checked in its own coordinates, which are rounded to whole pixels for
+// interpFn. A grid with reference bounds, such as a normalized grid, should be
+// checked with CheckCrossingsFor unless interpFn is linear.
+func (m *MorphGrid) CheckCrossings(interpFn InterpolationFunc, fractionFromStart float64) error {
+	return m.interpolatedGrid(interpFn, fractionFromStart).checkCrossings()
+}
+
+// CheckCrossingsFor verifies the interpolated grid like CheckCrossings, after
+// rescaling a grid with reference bounds to the bounds of the images it will morph,
+// as MorphFrame does. Returns an error if the bounds are empty.
+func (m *MorphGrid) CheckCrossingsFor(bounds image.Rectangle, interpFn InterpolationFunc, fractionFromStart float64) error {
+	if bounds.Empty() {
+		return errors.New("CheckCrossingsFor: Bounds must not be empty")
 	}
-	return
-}
-
-// allCubicCatmullRomSplines
-func (m *MorphGrid) allCubicCatmullRomSplines(vertical bool, alpha float64, totSteps int) (source, dest []*parametricLineFloat64, nSplines int, err error) {
-	source = nil
-	dest = nil
-	nSplines = 0
-	err = nil
-	nLoops := m.start.horizontalGridlineLen()
-	if vertical {
-		nLoops = m.start.verticalGridlineLen()
+	resolved, err := m.resolvedFor(bounds)
+	if err != nil {
+		return err
 	}
-	for i := 0; i < nLoops; i++ {
-		var sourcePts []image.Point = nil
-		var destPts []image.Point = nil
-		if vertical {
-			sourcePts, destPts = m.VerticalLine(i)
-		} else {
-			sourcePts, destPts = m.HorizontalLine(i)
-		}
-		if len(sourcePts) > 2 && len(destPts) > 2 {
-			sourceLine, err := CubicCatmullRomInterpolationImagePoints(sourcePts, alpha, totSteps)
-			if err != nil {
-				return nil, nil, 0, err
-			}
-			destLine, err := CubicCatmullRomInterpolationImagePoints(destPts, alpha, totSteps)
-			if err != nil {
-				return nil, nil, 0, err
-			}
-			source = append(source, newParametricLineFloat64())
-			dest = append(dest, newParametricLineFloat64())
-			source[len(source)-1].AddPoints(sourceLine)
-			dest[len(dest)-1].AddPoints(destLine)
-			nSplines++
+	return resolved.CheckCrossings(interpFn, fractionFromStart)
+}
+
+// mapped creates a new MorphGrid with the same topology and line style, whose start
+// and destination points are transformed by the given functions.
+func (m *MorphGrid) mapped(startFn, destFn func(Float64Point) Float64Point) *MorphGrid {
+	result := NewMorphGrid()
+	result.style = m.style
+	for _, index := range m.Intersections() {
+		startPt, destPt, err := m.Float64Points(index.HorizLine, index.VertLine)
+		if err != nil {
+			continue
 		}
+		result.AddFloat64Points(index.HorizLine, index.VertLine, startFn(startPt), destFn(destPt))
+	}
+	return result
+}
+
+// resolvedFor returns the grid rescaled to the given bounds if it has differing
+// reference bounds, otherwise the grid itself.
+func (m *MorphGrid) resolvedFor(bounds image.Rectangle) (*MorphGrid, error) {
+	if reference, ok := m.ReferenceBounds(); !ok || reference.Eq(bounds) {
+		return m, nil
 	}
-	return
+	return m.Rescale(bounds)
 }
 
-// interpolatedGrid
+// interpolatedGrid interpolates every pair of points with interpFn. Since an
+// InterpolationFunc operates on whole pixels, each point is rounded before being
+// passed to interpFn, and the sub-pixel remainders are interpolated linearly and
+// added to the result.
 func (m *MorphGrid) interpolatedGrid(interpFn InterpolationFunc, fractionFromStart float64) *float64CoordinateGrid {
 	interpGrid := newFloat64CoordinateGrid()
 	maxX := MaxInt(m.start.verticalGridlineLen(), m.dest.verticalGridlineLen())
 	maxY := MaxInt(m.start.horizontalGridlineLen(), m.dest.horizontalGridlineLen())
 	for x := 0; x < maxX; x++ {
 		for y := 0; y < maxY; y++ {
-			start, end, err := m.Points(y, x)
+			start, end, err := m.Float64Points(y, x)
 			if err == nil {
-				resultPt := interpFn(start, end, fractionFromStart)
-				interpGrid.addPoint(y, x, resultPt)
+				roundStart := roundFloat64Point(start)
+				roundEnd := roundFloat64Point(end)
+				resultPt := interpFn(roundStart, roundEnd, fractionFromStart)
+				remainder := LinearInterpolation(Float64Point{start.X - float64(roundStart.X), start.Y - float64(roundStart.Y)}, Float64Point{end.X - float64(roundEnd.X), end.Y - float64(roundEnd.Y)}, fractionFromStart)
+				interpGrid.addPoint(y, x, Float64Point{resultPt.X + remainder.X, resultPt.Y + remainder.Y})
 			}
 		}
 	}
@@ -0,0 +1,227 @@
+package gorph
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"math"
+	"strconv"
+)
+
+// morphGridBinaryMagic begins every binary encoded MorphGrid.
+const morphGridBinaryMagic = "GRPH"
+
+// morphGridBinaryVersion is the version of the binary format written by
+// MarshalBinary. Version 1 data, which lacks the line style, is still read.
+const morphGridBinaryVersion = 2
+
+// morphGridBinaryHeaderLen is the length of the magic, version and payload length.
+const morphGridBinaryHeaderLen = len(morphGridBinaryMagic) + 1 + 4
+
+// MarshalBinary implements encoding.BinaryMarshaler, which also makes a MorphGrid
+// encodable with encoding/gob. The format is the magic bytes "GRPH", a version byte,
+// the big-endian uint32 length of the payload, the payload, and the big-endian IEEE
+// CRC-32 checksum of the payload. The payload holds a flag byte marking whether
+// reference bounds are present, the reference bounds as four varints, the line
+// model and the start and end conditions of the lines as uvarints, the line fitter,
+// the number of
+// intersections as a uvarint, then for every intersection its horizontal and
+// vertical line indices as uvarints followed by the start and destination points as
+// four big-endian IEEE 754 float64 values. The line fitter is a kind byte, 0 when no
+// fitter is set, followed by the parameters of the kind: the alpha and end
+// conditions of a CatmullRomFitter (1), none for a MonotoneCubicFitter (2) or a
+// BSplineFitter (3), and the tension, bias and continuity of a
+// KochanekBartelsFitter (4). Returns an error if the fitter is not one of these,
+// such as a LineFitterFunc.
+func (m *MorphGrid) MarshalBinary() ([]byte, error) {
+	var payload bytes.Buffer
+	scratch := make([]byte, binary.MaxVarintLen64)
+	putVarint := func(value int64) {
+		payload.Write(scratch[:binary.PutVarint(scratch, value)])
+	}
+	putUvarint := func(value uint64) {
+		payload.Write(scratch[:binary.PutUvarint(scratch, value)])
+	}
+	putFloat := func(value float64) {
+		binary.BigEndian.PutUint64(scratch, math.Float64bits(value))
+		payload.Write(scratch[:8])
+	}
+	reference, ok := m.ReferenceBounds()
+	if ok {
+		payload.WriteByte(1)
+	} else {
+		payload.WriteByte(0)
+	}
+	putVarint(int64(reference.Min.X))
+	putVarint(int64(reference.Min.Y))
+	putVarint(int64(reference.Max.X))
+	putVarint(int64(reference.Max.Y))
+	putUvarint(uint64(m.LineModel()))
+	start, end := m.LineEnds()
+	putUvarint(uint64(start))
+	putUvarint(uint64(end))
+	fitter, err := m.style.encodedFitter()
+	if err != nil {
+		return nil, errors.New("MarshalBinary: " + err.Error())
+	}
+	switch f := fitter.(type) {
+	case nil:
+		payload.WriteByte(0)
+	case CatmullRomFitter:
+		payload.WriteByte(1)
+		putFloat(f.Alpha)
+		putUvarint(uint64(f.Start))
+		putUvarint(uint64(f.End))
+	case MonotoneCubicFitter:
+		payload.WriteByte(2)
+	case BSplineFitter:
+		payload.WriteByte(3)
+	case KochanekBartelsFitter:
+		payload.WriteByte(4)
+		putFloat(f.Tension)
+		putFloat(f.Bias)
+		putFloat(f.Continuity)
+	}
+	indices := m.Intersections()
+	putUvarint(uint64(len(indices)))
+	for _, index := range indices {
+		startPt, destPt, err := m.Float64Points(index.HorizLine, index.VertLine)
+		if err != nil {
+			return nil, err
+		}
+		putUvarint(uint64(index.HorizLine))
+		putUvarint(uint64(index.VertLine))
+		putFloat(startPt.X)
+		putFloat(startPt.Y)
+		putFloat(destPt.X)
+		putFloat(destPt.Y)
+	}
+
+	data := make([]byte, 0, morphGridBinaryHeaderLen+payload.Len()+4)
+	data = append(data, morphGridBinaryMagic...)
+	data = append(data, morphGridBinaryVersion)
+	data = binary.BigEndian.AppendUint32(data, uint32(payload.Len()))
+	data = append(data, payload.Bytes()...)
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(payload.Bytes()))
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the contents of
+// the grid with data written by MarshalBinary. Returns an error if the data is
+// truncated, has an unsupported version, fails its checksum or names an unknown
+// line model, end condition or line fitter, or a line index above 16384.
+func (m *MorphGrid) UnmarshalBinary(data []byte) error {
+	if len(data) < morphGridBinaryHeaderLen+4 || string(data[:len(morphGridBinaryMagic)]) != morphGridBinaryMagic {
+		return errors.New("UnmarshalBinary: Data is not a binary encoded MorphGrid")
+	}
+	version := data[len(morphGridBinaryMagic)]
+	if version < 1 || version > morphGridBinaryVersion {
+		return errors.New("UnmarshalBinary: Unsupported MorphGrid version " + strconv.Itoa(int(version)))
+	}
+	payloadLen := binary.BigEndian.Uint32(data[len(morphGridBinaryMagic)+1:])
+	if uint64(len(data)) != uint64(morphGridBinaryHeaderLen)+uint64(payloadLen)+4 {
+		return errors.New("UnmarshalBinary: Payload length does not match the length of the data")
+	}
+	payload := data[morphGridBinaryHeaderLen : morphGridBinaryHeaderLen+int(payloadLen)]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[morphGridBinaryHeaderLen+int(payloadLen):]) {
+		return errors.New("UnmarshalBinary: Checksum mismatch")
+	}
+
+	reader := bytes.NewReader(payload)
+	var readErr error
+	readVarint := func() int {
+		value, err := binary.ReadVarint(reader)
+		if err != nil && readErr == nil {
+			readErr = err
+		}
+		return int(value)
+	}
+	readUvarint := func() int {
+		value, err := binary.ReadUvarint(reader)
+		if err != nil && readErr == nil {
+			readErr = err
+		}
+		if value > math.MaxInt32 && readErr == nil {
+			readErr = errors.New("value out of range")
+		}
+		return int(value)
+	}
+	readFloat := func() float64 {
+		var bits uint64
+		err := binary.Read(reader, binary.BigEndian, &bits)
+		if err != nil && readErr == nil {
+			readErr = err
+		}
+		return math.Float64frombits(bits)
+	}
+	readEndCondition := func() EndCondition {
+		condition := EndCondition(readUvarint())
+		if _, ok := endConditionNames[condition]; !ok && readErr == nil {
+			readErr = errors.New("unknown end condition " + strconv.Itoa(int(condition)))
+		}
+		return condition
+	}
+	hasReference, err := reader.ReadByte()
+	if err != nil {
+		return errors.New("UnmarshalBinary: Malformed payload: " + err.Error())
+	}
+	reference := image.Rect(readVarint(), readVarint(), readVarint(), readVarint())
+	result := NewMorphGrid()
+	if hasReference != 0 {
+		result.SetReferenceBounds(reference)
+	}
+	if version >= 2 {
+		model := LineModel(readUvarint())
+		if _, ok := lineModelNames[model]; !ok && readErr == nil {
+			readErr = errors.New("unknown line model " + strconv.Itoa(int(model)))
+		}
+		result.SetLineModel(model)
+		start, end := readEndCondition(), readEndCondition()
+		result.SetLineEnds(start, end)
+		kind, err := reader.ReadByte()
+		if err != nil && readErr == nil {
+			readErr = err
+		}
+		switch kind {
+		case 0:
+		case 1:
+			alpha := readFloat()
+			start, end := readEndCondition(), readEndCondition()
+			result.SetLineFitter(CatmullRomFitter{alpha, start, end})
+		case 2:
+			result.SetLineFitter(MonotoneCubicFitter{})
+		case 3:
+			result.SetLineFitter(BSplineFitter{})
+		case 4:
+			tension, bias, continuity := readFloat(), readFloat(), readFloat()
+			result.SetLineFitter(KochanekBartelsFitter{KochanekBartels{Tension: tension, Bias: bias, Continuity: continuity}})
+		default:
+			if readErr == nil {
+				readErr = errors.New("unknown line fitter " + strconv.Itoa(int(kind)))
+			}
+		}
+	}
+	nPoints := readUvarint()
+	for i := 0; i < nPoints && readErr == nil; i++ {
+		horizLine := readUvarint()
+		vertLine := readUvarint()
+		startPt := Float64Point{readFloat(), readFloat()}
+		destPt := Float64Point{readFloat(), readFloat()}
+		if (horizLine > maxDecodedLineIndex || vertLine > maxDecodedLineIndex) && readErr == nil {
+			readErr = errors.New("line index of point " + strconv.Itoa(i) + " exceeds " + strconv.Itoa(maxDecodedLineIndex))
+		}
+		if readErr == nil {
+			result.AddFloat64Points(horizLine, vertLine, startPt, destPt)
+		}
+	}
+	if readErr != nil {
+		return errors.New("UnmarshalBinary: Malformed payload: " + readErr.Error())
+	}
+	if reader.Len() != 0 {
+		return errors.New("UnmarshalBinary: Malformed payload: trailing bytes")
+	}
+	*m = *result
+	return nil
+}
@@ -0,0 +1,181 @@
+package gorph
+
+import (
+	"bytes"
+	"encoding/gob"
+	"image"
+	"strconv"
+	"testing"
+)
+
+func binaryTestGrid() *MorphGrid {
+	m := NewMorphGrid()
+	m.AddFloat64Points(3, 2, Float64Point{1.25, 2}, Float64Point{3, 4.5})
+	m.AddPoints(3, 4, image.Point{2, 2}, image.Point{4, 6})
+	m.AddPoints(7, 4, image.Point{5, 7}, image.Point{-6, 10})
+	m.SetReferenceBounds(image.Rect(-8, 0, 512, 256))
+	return m
+}
+
+func assertBinaryTestGrid(t *testing.T, m *MorphGrid) {
+	AssertEqualsInt(t, len(m.Intersections()), 3, "Intersection count incorrect")
+	source, dest, err := m.Float64Points(3, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64Point(t, source, Float64Point{1.25, 2})
+	AssertEqualsFloat64Point(t, dest, Float64Point{3, 4.5})
+	source, dest, err = m.Float64Points(7, 4)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64Point(t, source, Float64Point{5, 7})
+	AssertEqualsFloat64Point(t, dest, Float64Point{-6, 10})
+	reference, ok := m.ReferenceBounds()
+	if !ok || !reference.Eq(image.Rect(-8, 0, 512, 256)) {
+		t.Error("Reference bounds incorrect")
+	}
+}
+
+func TestMorphGridBinaryRoundTrip(t *testing.T) {
+	data, err := binaryTestGrid().MarshalBinary()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var result MorphGrid
+	err = result.UnmarshalBinary(data)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assertBinaryTestGrid(t, &result)
+}
+
+func TestMorphGridBinaryLineModel(t *testing.T) {
+	m := binaryTestGrid()
+	m.SetLineModel(LineMonotoneCubic)
+	m.SetLineEnds(EndDuplicate, EndReflect)
+	data, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var result MorphGrid
+	err = result.UnmarshalBinary(data)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assertBinaryTestGrid(t, &result)
+	if result.LineModel() != LineMonotoneCubic {
+		t.Error("Line model incorrect: " + result.LineModel().String())
+	}
+	start, end := result.LineEnds()
+	if start != EndDuplicate || end != EndReflect {
+		t.Error("Line ends incorrect")
+	}
+}
+
+func TestMorphGridBinaryLineFitter(t *testing.T) {
+	fitters := []LineFitter{
+		CatmullRomFitter{1, EndNatural, EndReflect},
+		MonotoneCubicFitter{},
+		BSplineFitter{},
+		KochanekBartelsFitter{KochanekBartels{Tension: 0.5, Bias: -0.25, Continuity: 0.125}},
+	}
+	for i, fitter := range fitters {
+		m := binaryTestGrid()
+		m.SetLineFitter(fitter)
+		data, err := m.MarshalBinary()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		var result MorphGrid
+		err = result.UnmarshalBinary(data)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		assertBinaryTestGrid(t, &result)
+		if result.LineFitter() != fitter {
+			t.Error("Line fitter " + strconv.Itoa(i) + " incorrect")
+		}
+	}
+	m := binaryTestGrid()
+	m.SetLineFitter(LineFitterFunc(func(points []Float64Point, vertical bool) (*CubicSpline, error) {
+		return NewBSpline(points)
+	}))
+	_, err := m.MarshalBinary()
+	if err == nil {
+		t.Error("Expected error for a custom line fitter")
+	}
+}
+
+func TestMorphGridBinaryChecksum(t *testing.T) {
+	data, err := binaryTestGrid().MarshalBinary()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data[morphGridBinaryHeaderLen+2] ^= 0xff
+	var result MorphGrid
+	err = result.UnmarshalBinary(data)
+	if err == nil {
+		t.Error("Expected checksum error for corrupted data")
+	}
+}
+
+func TestMorphGridBinaryTruncated(t *testing.T) {
+	data, err := binaryTestGrid().MarshalBinary()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var result MorphGrid
+	err = result.UnmarshalBinary(data[:len(data)-1])
+	if err == nil {
+		t.Error("Expected error for truncated data")
+	}
+}
+
+func TestMorphGridBinaryLineIndexLimit(t *testing.T) {
+	m := NewMorphGrid()
+	m.AddPoints(0, maxDecodedLineIndex, image.Point{1, 1}, image.Point{2, 2})
+	data, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var result MorphGrid
+	err = result.UnmarshalBinary(data)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	m = NewMorphGrid()
+	m.AddPoints(maxDecodedLineIndex+1, 0, image.Point{1, 1}, image.Point{2, 2})
+	data, err = m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = result.UnmarshalBinary(data)
+	if err == nil {
+		t.Error("Expected error for a line index beyond the limit")
+	}
+}
+
+func TestMorphGridGob(t *testing.T) {
+	type job struct {
+		Name string
+		Grid *MorphGrid
+	}
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(job{"test", binaryTestGrid()})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var result job
+	err = gob.NewDecoder(&buf).Decode(&result)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.Name != "test" || result.Grid == nil {
+		t.Fatal("Decoded job incorrect")
+	}
+	assertBinaryTestGrid(t, result.Grid)
+	if result.Grid.LineModel() != LineCatmullRom {
+		t.Error("Line model incorrect: " + result.Grid.LineModel().String())
+	}
+}
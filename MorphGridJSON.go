@@ -0,0 +1,153 @@
+package gorph
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+	"strconv"
+)
+
+// morphGridJSONVersion is the version of the JSON document written by MarshalJSON.
+// Version 1 documents, which lack the line style, are still read.
+const morphGridJSONVersion = 2
+
+type morphGridJSON struct {
+	Version    int                  `json:"version"`
+	Reference  *[4]int              `json:"reference,omitempty"`
+	LineModel  string               `json:"lineModel,omitempty"`
+	LineEnds   *[2]string           `json:"lineEnds,omitempty"`
+	LineFitter *lineFitterJSON      `json:"lineFitter,omitempty"`
+	Points     []morphGridPointJSON `json:"points"`
+}
+
+// lineFitterJSON records a LineFitter set on a grid by its kind, "catmull-rom",
+// "monotone-cubic", "b-spline" or "kochanek-bartels", along with its parameters.
+type lineFitterJSON struct {
+	Kind       string  `json:"kind"`
+	Alpha      float64 `json:"alpha,omitempty"`
+	Start      string  `json:"start,omitempty"`
+	End        string  `json:"end,omitempty"`
+	Tension    float64 `json:"tension,omitempty"`
+	Bias       float64 `json:"bias,omitempty"`
+	Continuity float64 `json:"continuity,omitempty"`
+}
+
+// lineFitter converts the record back to a LineFitter.
+func (f lineFitterJSON) lineFitter() (LineFitter, error) {
+	switch f.Kind {
+	case "catmull-rom":
+		start, err := endConditionByName(f.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := endConditionByName(f.End)
+		if err != nil {
+			return nil, err
+		}
+		return CatmullRomFitter{f.Alpha, start, end}, nil
+	case "monotone-cubic":
+		return MonotoneCubicFitter{}, nil
+	case "b-spline":
+		return BSplineFitter{}, nil
+	case "kochanek-bartels":
+		return KochanekBartelsFitter{KochanekBartels{Tension: f.Tension, Bias: f.Bias, Continuity: f.Continuity}}, nil
+	}
+	return nil, errors.New("Unknown line fitter \"" + f.Kind + "\"")
+}
+
+type morphGridPointJSON struct {
+	HorizLine int        `json:"horizLine"`
+	VertLine  int        `json:"vertLine"`
+	Start     [2]float64 `json:"start"`
+	Dest      [2]float64 `json:"dest"`
+}
+
+// MarshalJSON implements json.Marshaler. The document lists every intersection by
+// its line indices along with both of its points, so sparse grids are preserved.
+// Reference bounds are written as [minX, minY, maxX, maxY] when present, and the
+// line model and line ends by their names. A LineFitter set on the grid is written
+// by its kind and parameters. Returns an error if the fitter is not one of the
+// fitters of this package, such as a LineFitterFunc.
+func (m *MorphGrid) MarshalJSON() ([]byte, error) {
+	doc := morphGridJSON{Version: morphGridJSONVersion, LineModel: m.LineModel().String(), Points: []morphGridPointJSON{}}
+	start, end := m.LineEnds()
+	doc.LineEnds = &[2]string{endConditionNames[start], endConditionNames[end]}
+	fitter, err := m.style.encodedFitter()
+	if err != nil {
+		return nil, errors.New("MarshalJSON: " + err.Error())
+	}
+	switch f := fitter.(type) {
+	case CatmullRomFitter:
+		doc.LineFitter = &lineFitterJSON{Kind: "catmull-rom", Alpha: f.Alpha, Start: endConditionNames[f.Start], End: endConditionNames[f.End]}
+	case MonotoneCubicFitter:
+		doc.LineFitter = &lineFitterJSON{Kind: "monotone-cubic"}
+	case BSplineFitter:
+		doc.LineFitter = &lineFitterJSON{Kind: "b-spline"}
+	case KochanekBartelsFitter:
+		doc.LineFitter = &lineFitterJSON{Kind: "kochanek-bartels", Tension: f.Tension, Bias: f.Bias, Continuity: f.Continuity}
+	}
+	if reference, ok := m.ReferenceBounds(); ok {
+		doc.Reference = &[4]int{reference.Min.X, reference.Min.Y, reference.Max.X, reference.Max.Y}
+	}
+	for _, index := range m.Intersections() {
+		startPt, destPt, err := m.Float64Points(index.HorizLine, index.VertLine)
+		if err != nil {
+			return nil, err
+		}
+		doc.Points = append(doc.Points, morphGridPointJSON{index.HorizLine, index.VertLine, [2]float64{startPt.X, startPt.Y}, [2]float64{destPt.X, destPt.Y}})
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing the contents of the grid with
+// those of a document written by MarshalJSON. Returns an error for an unsupported
+// version, an unknown line model, end condition or line fitter, or line indices
+// that are negative or above 16384.
+func (m *MorphGrid) UnmarshalJSON(data []byte) error {
+	var doc morphGridJSON
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return err
+	}
+	if doc.Version < 1 || doc.Version > morphGridJSONVersion {
+		return errors.New("UnmarshalJSON: Unsupported MorphGrid version " + strconv.Itoa(doc.Version))
+	}
+	result := NewMorphGrid()
+	model, err := LineModelByName(doc.LineModel)
+	if err != nil {
+		return errors.New("UnmarshalJSON: " + err.Error())
+	}
+	result.SetLineModel(model)
+	if doc.LineEnds != nil {
+		start, err := endConditionByName(doc.LineEnds[0])
+		if err != nil {
+			return errors.New("UnmarshalJSON: " + err.Error())
+		}
+		end, err := endConditionByName(doc.LineEnds[1])
+		if err != nil {
+			return errors.New("UnmarshalJSON: " + err.Error())
+		}
+		result.SetLineEnds(start, end)
+	}
+	if doc.LineFitter != nil {
+		fitter, err := doc.LineFitter.lineFitter()
+		if err != nil {
+			return errors.New("UnmarshalJSON: " + err.Error())
+		}
+		result.SetLineFitter(fitter)
+	}
+	if doc.Reference != nil {
+		result.SetReferenceBounds(image.Rect(doc.Reference[0], doc.Reference[1], doc.Reference[2], doc.Reference[3]))
+	}
+	for i, pt := range doc.Points {
+		if pt.HorizLine < 0 || pt.VertLine < 0 {
+			return errors.New("UnmarshalJSON: Negative line index for point " + strconv.Itoa(i))
+		}
+		if pt.HorizLine > maxDecodedLineIndex || pt.VertLine > maxDecodedLineIndex {
+			return errors.New("UnmarshalJSON: Line index of point " + strconv.Itoa(i) + " exceeds " + strconv.Itoa(maxDecodedLineIndex))
+		}
+		result.AddFloat64Points(pt.HorizLine, pt.VertLine, Float64Point{pt.Start[0], pt.Start[1]}, Float64Point{pt.Dest[0], pt.Dest[1]})
+	}
+	*m = *result
+	return nil
+}
@@ -0,0 +1,150 @@
+package gorph
+
+import (
+	"encoding/json"
+	"image"
+	"strconv"
+	"testing"
+)
+
+func TestMorphGridJSONRoundTrip(t *testing.T) {
+	m := NewMorphGrid()
+	m.AddFloat64Points(3, 2, Float64Point{1.25, 2}, Float64Point{3, 4.5})
+	m.AddPoints(3, 4, image.Point{2, 2}, image.Point{4, 6})
+	m.AddPoints(7, 4, image.Point{5, 7}, image.Point{6, 10})
+	m.SetReferenceBounds(image.Rect(0, 0, 512, 256))
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	LogVerbose(t, string(data))
+	var result MorphGrid
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(result.Intersections()), 3, "Intersection count incorrect")
+	AssertEqualsInt(t, result.HorizontalGridlineCount(), 2, "HorizontalGridlineCount incorrect")
+	AssertEqualsInt(t, result.VerticalGridlineCount(), 2, "VerticalGridlineCount incorrect")
+	source, dest, err := result.Float64Points(3, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64Point(t, source, Float64Point{1.25, 2})
+	AssertEqualsFloat64Point(t, dest, Float64Point{3, 4.5})
+	source, dest, err = result.Float64Points(7, 4)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64Point(t, source, Float64Point{5, 7})
+	AssertEqualsFloat64Point(t, dest, Float64Point{6, 10})
+	reference, ok := result.ReferenceBounds()
+	if !ok || !reference.Eq(image.Rect(0, 0, 512, 256)) {
+		t.Error("Reference bounds incorrect")
+	}
+}
+
+func TestMorphGridJSONUnsupportedVersion(t *testing.T) {
+	var m MorphGrid
+	err := json.Unmarshal([]byte(`{"version": 99, "points": []}`), &m)
+	if err == nil {
+		t.Error("Expected error for unsupported version")
+	}
+}
+
+func TestMorphGridJSONLineIndexLimit(t *testing.T) {
+	var m MorphGrid
+	err := json.Unmarshal([]byte(`{"version": 2, "points": [{"horizLine": 0, "vertLine": 2000000000, "start": [0, 0], "dest": [0, 0]}]}`), &m)
+	if err == nil {
+		t.Error("Expected error for a line index beyond the limit")
+	}
+}
+
+func TestMorphGridJSONLineModel(t *testing.T) {
+	m := NewMorphGrid()
+	m.AddPoints(0, 0, image.Point{1, 1}, image.Point{2, 2})
+	m.SetLineModel(LineMonotoneCubic)
+	m.SetLineEnds(EndReflect, EndNatural)
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var result MorphGrid
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.LineModel() != LineMonotoneCubic {
+		t.Error("Line model incorrect: " + result.LineModel().String())
+	}
+	start, end := result.LineEnds()
+	if start != EndReflect || end != EndNatural {
+		t.Error("Line ends incorrect")
+	}
+}
+
+func TestMorphGridJSONVersion1(t *testing.T) {
+	var m MorphGrid
+	err := json.Unmarshal([]byte(`{"version": 1, "points": [{"horizLine": 1, "vertLine": 2, "start": [3, 4], "dest": [5, 6]}]}`), &m)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(m.Intersections()), 1, "Intersection count incorrect")
+	if m.LineModel() != LineCatmullRom {
+		t.Error("Line model of a version 1 document should be Catmull-Rom")
+	}
+}
+
+func TestMorphGridJSONUnknownLineModel(t *testing.T) {
+	var m MorphGrid
+	err := json.Unmarshal([]byte(`{"version": 2, "lineModel": "bezier", "points": []}`), &m)
+	if err == nil {
+		t.Error("Expected error for unknown line model")
+	}
+	err = json.Unmarshal([]byte(`{"version": 2, "lineEnds": ["reflect", "clamp"], "points": []}`), &m)
+	if err == nil {
+		t.Error("Expected error for unknown end condition")
+	}
+}
+
+func TestMorphGridJSONLineFitter(t *testing.T) {
+	fitters := []LineFitter{
+		CatmullRomFitter{0.25, EndReflect, EndDuplicate},
+		MonotoneCubicFitter{},
+		BSplineFitter{},
+		KochanekBartelsFitter{KochanekBartels{Tension: 0.5, Bias: -0.25, Continuity: 0.125}},
+		&KochanekBartelsFitter{KochanekBartels{Tension: -1}},
+	}
+	for i, fitter := range fitters {
+		m := NewMorphGrid()
+		m.AddPoints(0, 0, image.Point{1, 1}, image.Point{2, 2})
+		m.SetLineFitter(fitter)
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		var result MorphGrid
+		err = json.Unmarshal(data, &result)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		want := fitter
+		if ptr, ok := fitter.(*KochanekBartelsFitter); ok {
+			want = *ptr
+		}
+		if result.LineFitter() != want {
+			t.Error("Line fitter " + strconv.Itoa(i) + " incorrect: " + string(data))
+		}
+	}
+}
+
+func TestMorphGridJSONCustomLineFitter(t *testing.T) {
+	m := NewMorphGrid()
+	m.SetLineFitter(LineFitterFunc(func(points []Float64Point, vertical bool) (*CubicSpline, error) {
+		return NewBSpline(points)
+	}))
+	_, err := json.Marshal(m)
+	if err == nil {
+		t.Error("Expected error for a custom line fitter")
+	}
+}
@@ -229,3 +229,161 @@ func TestMorphGridInterpolatedGrid(t *testing.T) {
 	AssertEqualsFloat64PointTolerance(t, p2, Float64Point{2.5, 3}, 0.000001, "Interpolation point incorrect")
 	AssertEqualsFloat64PointTolerance(t, p3, Float64Point{5.25, 7.75}, 0.000001, "Interpolation point incorrect")
 }
+
+func TestMorphGridIntersections(t *testing.T) {
+	m := NewMorphGrid()
+	m.AddPoints(3, 2, image.Point{1, 2}, image.Point{3, 4})
+	m.AddPoints(3, 4, image.Point{2, 2}, image.Point{4, 6})
+	m.AddPoints(1, 4, image.Point{5, 7}, image.Point{6, 10})
+	indices := m.Intersections()
+	AssertEqualsInt(t, len(indices), 3, "Intersection count incorrect")
+	AssertEqualsInt(t, indices[0].HorizLine, 1)
+	AssertEqualsInt(t, indices[0].VertLine, 4)
+	AssertEqualsInt(t, indices[1].HorizLine, 3)
+	AssertEqualsInt(t, indices[1].VertLine, 2)
+	AssertEqualsInt(t, indices[2].HorizLine, 3)
+	AssertEqualsInt(t, indices[2].VertLine, 4)
+}
+
+func TestMorphGridCheckCrossings(t *testing.T) {
+	m := NewMorphGrid()
+	m.AddPoints(0, 0, image.Point{0, 0}, image.Point{0, 0})
+	m.AddPoints(0, 1, image.Point{4, 0}, image.Point{4, 0})
+	m.AddPoints(1, 0, image.Point{0, 4}, image.Point{0, 4})
+	m.AddPoints(1, 1, image.Point{4, 4}, image.Point{2, 2})
+	err := m.CheckCrossings(LinearInterpolationImagePoints, 1.5)
+	if err != nil {
+		LogVerbose(t, err.Error())
+		t.Fail()
+	}
+	err = m.CheckCrossings(LinearInterpolationImagePoints, 3)
+	if err == nil {
+		t.Error("Expected grid lines to cross")
+	}
+}
+
+func TestMorphGridCheckCrossingsFor(t *testing.T) {
+	m := NewNormalizedMorphGrid()
+	normalize := ScaleAffine(1.0/64, 1.0/64)
+	pixelGrid := squareMorphGrid(64, 64, image.Point{32, 32})
+	for _, index := range pixelGrid.Intersections() {
+		startPt, destPt, _ := pixelGrid.Float64Points(index.HorizLine, index.VertLine)
+		m.AddFloat64Points(index.HorizLine, index.VertLine, normalize.Apply(startPt), normalize.Apply(destPt))
+	}
+	if pixelGrid.CheckCrossings(pushMiddleColumn, 0.5) == nil {
+		t.Fatal("Pushed column does not cross")
+	}
+	err := m.CheckCrossingsFor(image.Rect(0, 0, 64, 64), pushMiddleColumn, 0.5)
+	if err == nil {
+		t.Error("Expected the rescaled grid lines to cross")
+	}
+	err = m.CheckCrossingsFor(image.Rect(0, 0, 64, 64), LinearInterpolationImagePoints, 0.5)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	err = m.CheckCrossingsFor(image.ZR, LinearInterpolationImagePoints, 0.5)
+	if err == nil {
+		t.Error("Expected error for empty bounds")
+	}
+}
+
+func TestMorphGridRescale(t *testing.T) {
+	m := NewMorphGrid()
+	m.AddPoints(0, 0, image.Point{64, 128}, image.Point{256, 512})
+	_, err := m.Rescale(image.Rect(0, 0, 4096, 4096))
+	if err == nil {
+		t.Error("Expected error rescaling a grid without reference bounds")
+	}
+	m.SetReferenceBounds(image.Rect(0, 0, 512, 512))
+	rescaled, err := m.Rescale(image.Rect(0, 0, 4096, 2048))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	source, dest, err := rescaled.Points(0, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsImagePoint(t, source, image.Point{512, 512})
+	AssertEqualsImagePoint(t, dest, image.Point{2048, 2048})
+	reference, ok := rescaled.ReferenceBounds()
+	if !ok || !reference.Eq(image.Rect(0, 0, 4096, 2048)) {
+		t.Error("Rescaled grid reference bounds incorrect")
+	}
+}
+
+func TestMorphGridFloat64Points(t *testing.T) {
+	m := NewMorphGrid()
+	m.AddFloat64Points(3, 2, Float64Point{1.25, 2.5}, Float64Point{3.75, 4.4})
+	m.AddFloat64Points(4, 2, Float64Point{1.5, 6.5}, Float64Point{3.5, 8.5})
+	source, dest, err := m.Float64Points(3, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64Point(t, source, Float64Point{1.25, 2.5})
+	AssertEqualsFloat64Point(t, dest, Float64Point{3.75, 4.4})
+	roundSource, roundDest, err := m.Points(3, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsImagePoint(t, roundSource, image.Point{1, 3})
+	AssertEqualsImagePoint(t, roundDest, image.Point{4, 4})
+	sourceLine, destLine := m.VerticalLineFloat64(2)
+	AssertEqualsInt(t, len(sourceLine), 2, "Source length incorrect")
+	AssertEqualsInt(t, len(destLine), 2, "Destination length incorrect")
+	AssertEqualsFloat64Point(t, sourceLine[1], Float64Point{1.5, 6.5})
+	AssertEqualsFloat64Point(t, destLine[1], Float64Point{3.5, 8.5})
+}
+
+func TestMorphGridAddPointsReplaces(t *testing.T) {
+	m := NewMorphGrid()
+	m.AddPoints(3, 2, image.Point{1, 2}, image.Point{3, 4})
+	m.AddPoints(3, 2, image.Point{5, 6}, image.Point{7, 8})
+	source, dest := m.HorizontalLine(3)
+	AssertEqualsInt(t, len(source), 1, "Source length incorrect")
+	AssertEqualsInt(t, len(dest), 1, "Destination length incorrect")
+	AssertEqualsImagePoint(t, source[0], image.Point{5, 6})
+	AssertEqualsImagePoint(t, dest[0], image.Point{7, 8})
+}
+
+func TestNormalizedMorphGrid(t *testing.T) {
+	m := NewNormalizedMorphGrid()
+	m.AddFloat64Points(0, 0, Float64Point{0.25, 0.5}, Float64Point{0.125, 1})
+	rescaled, err := m.Rescale(image.Rect(0, 0, 4096, 2160))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	source, dest, err := rescaled.Float64Points(0, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, source, Float64Point{1024, 1080}, .000001)
+	AssertEqualsFloat64PointTolerance(t, dest, Float64Point{512, 2160}, .000001)
+}
+
+func TestMorphGridInterpolatedGridSubPixel(t *testing.T) {
+	m := NewMorphGrid()
+	m.AddFloat64Points(0, 0, Float64Point{1.25, 2.75}, Float64Point{3.5, 4.25})
+	g := m.interpolatedGrid(LinearInterpolationImagePoints, 0.5)
+	pt, err := g.point(0, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, pt, Float64Point{2.375, 3.5}, .000001)
+}
+
+func TestMorphGridLineEnds(t *testing.T) {
+	mGrid := squareMorphGrid(8, 8, image.Point{5, 5})
+	mGrid.SetLineEnds(EndReflect, EndNatural)
+	start, end := mGrid.LineEnds()
+	AssertEqualsInt(t, int(start), int(EndReflect), "Wrong start end condition")
+	AssertEqualsInt(t, int(end), int(EndNatural), "Wrong end end condition")
+	rescaled := mGrid.mapped(func(pt Float64Point) Float64Point { return pt }, func(pt Float64Point) Float64Point { return pt })
+	start, end = rescaled.LineEnds()
+	AssertEqualsInt(t, int(start), int(EndReflect), "Copy lost its start end condition")
+	AssertEqualsInt(t, int(end), int(EndNatural), "Copy lost its end end condition")
+	img := image.NewRGBA64(image.Rect(0, 0, 8, 8))
+	_, err := MorphFrame(0.5, img, img, *mGrid, LinearInterpolationImagePoints, func(t float64) float64 { return t })
+	if err != nil {
+		t.Error(err.Error())
+	}
+}
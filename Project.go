@@ -0,0 +1,150 @@
+package gorph
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+	_ "image/gif"  // Register GIF decoding for project images
+	_ "image/jpeg" // Register JPEG decoding for project images
+	_ "image/png"  // Register PNG decoding for project images
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// MorphProjectVersion is the version of the morph project document written by this
+// package.
+const MorphProjectVersion = 1
+
+// MorphProject declaratively describes a morph job as a JSON document, so that a
+// morph may be saved and rerun without rebuilding its MorphGrid in code. The start
+// and destination images are referenced by path; relative paths are resolved against
+// the directory of the project file when it is read with LoadMorphProject.
+type MorphProject struct {
+	Version int `json:"version"`
+	// Start and Dest are the paths of the start and destination images.
+	Start string `json:"start"`
+	Dest  string `json:"dest"`
+	// Grid holds the homogulous points of both images.
+	Grid *MorphGrid `json:"grid"`
+	// Frames is the number of morph images to create, as given to Morph.
+	Frames int `json:"frames"`
+	// GeometryEasing and ColorEasing name the easing functions, as accepted by
+	// EasingByName, applied to the grid interpolation and to the cross fading.
+	GeometryEasing string `json:"geometryEasing,omitempty"`
+	ColorEasing    string `json:"colorEasing,omitempty"`
+	// LineModel names the line model of the grid, as accepted by LineModelByName.
+	// When empty, the grid's own line model or line fitter is used. Otherwise it
+	// replaces both, so the grid's line fitter is ignored.
+	LineModel string `json:"lineModel,omitempty"`
+
+	dir string
+}
+
+// ReadMorphProject decodes a morph project document. Relative image paths are
+// resolved against the working directory.
+func ReadMorphProject(r io.Reader) (*MorphProject, error) {
+	var p MorphProject
+	err := json.NewDecoder(r).Decode(&p)
+	if err != nil {
+		return nil, err
+	}
+	if p.Version != MorphProjectVersion {
+		return nil, errors.New("ReadMorphProject: Unsupported project version " + strconv.Itoa(p.Version))
+	}
+	if p.Grid == nil {
+		return nil, errors.New("ReadMorphProject: Project has no grid")
+	}
+	if _, err = EasingByName(p.GeometryEasing); err != nil {
+		return nil, err
+	}
+	if _, err = EasingByName(p.ColorEasing); err != nil {
+		return nil, err
+	}
+	if _, err = LineModelByName(p.LineModel); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// LoadMorphProject reads a morph project document from a file. Relative image paths
+// are resolved against the directory containing the file.
+func LoadMorphProject(path string) (*MorphProject, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := ReadMorphProject(f)
+	if err != nil {
+		return nil, err
+	}
+	p.dir = filepath.Dir(path)
+	return p, nil
+}
+
+// Write encodes the project as a JSON document, setting its version to
+// MorphProjectVersion.
+func (p *MorphProject) Write(w io.Writer) error {
+	p.Version = MorphProjectVersion
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(p)
+}
+
+// Run loads the project's images and morphs them as described by the project.
+func (p *MorphProject) Run() ([]image.Image, error) {
+	if p.Grid == nil {
+		return nil, errors.New("Run: Project has no grid")
+	}
+	geometryEasing, err := EasingByName(p.GeometryEasing)
+	if err != nil {
+		return nil, err
+	}
+	colorEasing, err := EasingByName(p.ColorEasing)
+	if err != nil {
+		return nil, err
+	}
+	grid, err := p.morphGrid()
+	if err != nil {
+		return nil, err
+	}
+	start, err := p.loadImage(p.Start)
+	if err != nil {
+		return nil, err
+	}
+	dest, err := p.loadImage(p.Dest)
+	if err != nil {
+		return nil, err
+	}
+	return Morph(p.Frames, start, dest, grid, EasedLinearInterpolation(geometryEasing), colorEasing)
+}
+
+// morphGrid returns a copy of the project's grid with its line model applied. The
+// line model takes precedence over the grid's line fitter, which is cleared.
+func (p *MorphProject) morphGrid() (MorphGrid, error) {
+	grid := *p.Grid
+	if p.LineModel != "" {
+		model, err := LineModelByName(p.LineModel)
+		if err != nil {
+			return MorphGrid{}, err
+		}
+		grid.SetLineModel(model)
+		grid.SetLineFitter(nil)
+	}
+	return grid, nil
+}
+
+func (p *MorphProject) loadImage(path string) (image.Image, error) {
+	if !filepath.IsAbs(path) && p.dir != "" {
+		path = filepath.Join(p.dir, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
@@ -0,0 +1,95 @@
+package gorph
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMorphProjectRoundTrip(t *testing.T) {
+	p := &MorphProject{Start: "a.png", Dest: "b.png", Grid: squareMorphGrid(8, 8, image.Point{5, 5}), Frames: 3, ColorEasing: "ease-in-out"}
+	var buf bytes.Buffer
+	err := p.Write(&buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	result, err := ReadMorphProject(&buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, result.Frames, 3, "Frames incorrect")
+	AssertEqualsInt(t, len(result.Grid.Intersections()), 9, "Grid intersections incorrect")
+	if result.Start != "a.png" || result.Dest != "b.png" || result.ColorEasing != "ease-in-out" {
+		t.Error("Project fields incorrect")
+	}
+}
+
+func TestReadMorphProjectUnknownEasing(t *testing.T) {
+	doc := `{"version": 1, "start": "a.png", "dest": "b.png", "grid": {"version": 1, "points": []}, "frames": 1, "colorEasing": "bounce"}`
+	_, err := ReadMorphProject(strings.NewReader(doc))
+	if err == nil {
+		t.Error("Expected error for unknown easing")
+	}
+}
+
+func TestLoadMorphProjectRun(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.png", "b.png"} {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		err = png.Encode(f, image.NewRGBA64(image.Rect(0, 0, 8, 8)))
+		f.Close()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	p := &MorphProject{Start: "a.png", Dest: "b.png", Grid: squareMorphGrid(8, 8, image.Point{5, 5}), Frames: 2}
+	f, err := os.Create(filepath.Join(dir, "project.json"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = p.Write(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	loaded, err := LoadMorphProject(filepath.Join(dir, "project.json"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	results, err := loaded.Run()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(results), 2, "Number of morphs incorrect")
+}
+
+func TestMorphProjectLineModelOverridesFitter(t *testing.T) {
+	grid := squareMorphGrid(8, 8, image.Point{5, 5})
+	grid.SetLineFitter(KochanekBartelsFitter{Tension: 0.5})
+	p := &MorphProject{Start: "a.png", Dest: "b.png", Grid: grid, Frames: 1}
+	result, err := p.morphGrid()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, ok := result.LineFitter().(KochanekBartelsFitter); !ok {
+		t.Error("Grid's line fitter not used without a project line model")
+	}
+	p.LineModel = "monotone-cubic"
+	result, err = p.morphGrid()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, ok := result.LineFitter().(MonotoneCubicFitter); !ok {
+		t.Error("Project line model did not replace the grid's line fitter")
+	}
+	if _, ok := grid.LineFitter().(KochanekBartelsFitter); !ok {
+		t.Error("Project line model changed the project's grid")
+	}
+}
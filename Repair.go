@@ -0,0 +1,243 @@
+package gorph
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// PointMove records a point moved by MorphGrid.RepairFolds.
+type PointMove struct {
+	Index GridIndex
+	// Side is GridStart or GridDest.
+	Side GridSide
+	From Float64Point
+	To   Float64Point
+}
+
+// RepairOptions configures MorphGrid.RepairFolds. The zero value uses defaults.
+type RepairOptions struct {
+	// Validate configures the validation run after every repair step.
+	Validate ValidateOptions
+	// MinGap is the smallest distance kept between neighboring points along the
+	// sweep axis of a line. Defaults to one hundredth of the mean spacing of the
+	// line's points.
+	MinGap float64
+	// Straighten is the fraction in (0.0, 1.0] by which each point of a folded line
+	// is pulled across the sweep axis towards the chord between its neighbors on
+	// every step. Defaults to 0.25.
+	Straighten float64
+	// MaxIterations bounds the number of repair steps. Defaults to 32.
+	MaxIterations int
+}
+
+// RepairFolds attempts to fix the folded and crossing lines reported by Validate
+// by nudging grid points as little as it can. Points out of order along a line are
+// moved to the closest ordering in the least squares sense, and a line whose spline
+// still folds back on itself is then gradually straightened across its sweep axis
+// until it passes. Neighboring lines whose splines cross between their points are
+// straightened the same way. A fold in an interpolated grid is repaired on both the
+// start and destination grids.
+//
+// The returned grid is a repaired copy and the original is left untouched. The moves
+// list the original and final location of every point that moved. Only folds and
+// crossings are repaired; an error is returned if they remain after the maximum
+// number of iterations.
+func (m *MorphGrid) RepairFolds(opts RepairOptions) (*MorphGrid, []PointMove, error) {
+	straighten := opts.Straighten
+	if straighten <= 0 || straighten > 1 {
+		straighten = 0.25
+	}
+	maxIterations := opts.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = 32
+	}
+	repaired := m.mapped(func(pt Float64Point) Float64Point { return pt }, func(pt Float64Point) Float64Point { return pt })
+	repaired.reference = m.reference
+	for iteration := 0; ; iteration++ {
+		folds := foldProblems(repaired.ValidateWith(opts.Validate))
+		if len(folds) == 0 {
+			break
+		} else if iteration == maxIterations {
+			return nil, nil, errors.New("RepairFolds: " + strconv.Itoa(len(folds)) + " problems remain after " + strconv.Itoa(maxIterations) + " iterations, the first being: " + folds[0].String())
+		}
+		repaired.repairStep(folds, opts.MinGap, straighten)
+	}
+	var moves []PointMove
+	for _, index := range m.Intersections() {
+		startPt, destPt, _ := m.Float64Points(index.HorizLine, index.VertLine)
+		newStart, newDest, _ := repaired.Float64Points(index.HorizLine, index.VertLine)
+		if newStart != startPt {
+			moves = append(moves, PointMove{index, GridStart, startPt, newStart})
+		}
+		if newDest != destPt {
+			moves = append(moves, PointMove{index, GridDest, destPt, newDest})
+		}
+	}
+	return repaired, moves, nil
+}
+
+// foldProblems filters the problems RepairFolds is able to repair.
+func foldProblems(problems []GridProblem) []GridProblem {
+	var folds []GridProblem
+	for _, problem := range problems {
+		if problem.Kind == ProblemNonMonotone || problem.Kind == ProblemCrossingLines {
+			folds = append(folds, problem)
+		}
+	}
+	return folds
+}
+
+// repairLine identifies a line of one side of the grid to be repaired.
+type repairLine struct {
+	side     GridSide
+	vertical bool
+	line     int
+}
+
+// repairStep applies a single round of repairs to the lines involved in the given
+// problems. A crossing of two lines at their points means the points along the
+// perpendicular line are out of order, so it is repaired on that perpendicular line.
+// Lines whose splines cross between their points are straightened themselves.
+func (m *MorphGrid) repairStep(problems []GridProblem, minGap, straighten float64) {
+	seen := make(map[repairLine]bool)
+	var lines []repairLine
+	for _, problem := range problems {
+		targets := []repairLine{{problem.Side, problem.Vertical, problem.Line}}
+		if problem.Kind == ProblemCrossingLines && problem.CrossLine >= 0 {
+			targets = []repairLine{{problem.Side, !problem.Vertical, problem.CrossLine}}
+		} else if problem.Kind == ProblemCrossingLines {
+			// Splines meeting between their points bulge into each other
+			targets = append(targets, repairLine{problem.Side, problem.Vertical, problem.OtherLine})
+		}
+		for _, target := range targets {
+			sides := []GridSide{target.side}
+			if target.side == GridInterpolated {
+				sides = []GridSide{GridStart, GridDest}
+			}
+			for _, side := range sides {
+				key := repairLine{side, target.vertical, target.line}
+				if !seen[key] {
+					seen[key] = true
+					lines = append(lines, key)
+				}
+			}
+		}
+	}
+	for _, key := range lines {
+		grid := m.start
+		if key.side == GridDest {
+			grid = m.dest
+		}
+		indices, pts := lineIndices(grid, key.vertical, key.line)
+		if len(pts) < 2 {
+			continue
+		}
+		repairedPts := orderLine(pts, key.vertical, minGap)
+		if equalFloat64Points(repairedPts, pts) {
+			repairedPts = straightenLine(pts, key.vertical, straighten)
+		}
+		for i, index := range indices {
+			_ = grid.removePoint(index.HorizLine, index.VertLine)
+			grid.addPoint(index.HorizLine, index.VertLine, repairedPts[i])
+		}
+	}
+}
+
+// lineIndices returns the indices and points of a line in order. Intersections are
+// row-major, so the points of either kind of line come out in order.
+func lineIndices(grid *float64CoordinateGrid, vertical bool, line int) ([]GridIndex, []Float64Point) {
+	var indices []GridIndex
+	var pts []Float64Point
+	for _, index := range grid.intersections() {
+		if (vertical && index.VertLine == line) || (!vertical && index.HorizLine == line) {
+			pt, _ := grid.point(index.HorizLine, index.VertLine)
+			indices = append(indices, index)
+			pts = append(pts, pt)
+		}
+	}
+	return indices, pts
+}
+
+// orderLine moves the points of a line the least distance, in the least squares
+// sense, so they strictly increase along the sweep axis by at least minGap. This is
+// the pool adjacent violators algorithm applied to the sweep coordinates offset by
+// the gap.
+func orderLine(pts []Float64Point, vertical bool, minGap float64) []Float64Point {
+	n := len(pts)
+	values := make([]float64, n)
+	for i, pt := range pts {
+		values[i] = pt.X
+		if vertical {
+			values[i] = pt.Y
+		}
+	}
+	if minGap <= 0 {
+		minGap = math.Abs(values[n-1]-values[0]) / float64(n-1) / 100
+		if minGap == 0 {
+			minGap = 1e-3
+		}
+	}
+	var blockMeans []float64
+	var blockSizes []int
+	for i, v := range values {
+		blockMeans = append(blockMeans, v-float64(i)*minGap)
+		blockSizes = append(blockSizes, 1)
+		for len(blockMeans) > 1 && blockMeans[len(blockMeans)-2] > blockMeans[len(blockMeans)-1] {
+			last := len(blockMeans) - 1
+			size := blockSizes[last-1] + blockSizes[last]
+			blockMeans[last-1] = (blockMeans[last-1]*float64(blockSizes[last-1]) + blockMeans[last]*float64(blockSizes[last])) / float64(size)
+			blockSizes[last-1] = size
+			blockMeans = blockMeans[:last]
+			blockSizes = blockSizes[:last]
+		}
+	}
+	result := make([]Float64Point, n)
+	copy(result, pts)
+	i := 0
+	for b, mean := range blockMeans {
+		for j := 0; j < blockSizes[b]; j, i = j+1, i+1 {
+			if values[i] == mean+float64(i)*minGap {
+				continue
+			}
+			if vertical {
+				result[i].Y = mean + float64(i)*minGap
+			} else {
+				result[i].X = mean + float64(i)*minGap
+			}
+		}
+	}
+	return result
+}
+
+// straightenLine pulls every interior point of a line across its sweep axis towards
+// the chord between its neighbors by the given fraction.
+func straightenLine(pts []Float64Point, vertical bool, fraction float64) []Float64Point {
+	result := make([]Float64Point, len(pts))
+	copy(result, pts)
+	for i := 1; i < len(pts)-1; i++ {
+		prev, next := pts[i-1], pts[i+1]
+		if vertical {
+			s := (pts[i].Y - prev.Y) / (next.Y - prev.Y)
+			chord := prev.X + s*(next.X-prev.X)
+			result[i].X += fraction * (chord - pts[i].X)
+		} else {
+			s := (pts[i].X - prev.X) / (next.X - prev.X)
+			chord := prev.Y + s*(next.Y-prev.Y)
+			result[i].Y += fraction * (chord - pts[i].Y)
+		}
+	}
+	return result
+}
+
+func equalFloat64Points(a, b []Float64Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
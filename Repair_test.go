@@ -0,0 +1,82 @@
+package gorph
+
+import (
+	"image"
+	"testing"
+)
+
+func TestRepairFoldsValidGrid(t *testing.T) {
+	mGrid := squareMorphGrid(64, 64, image.Point{40, 40})
+	repaired, moves, err := mGrid.RepairFolds(RepairOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(moves), 0, "Valid grid should not move")
+	AssertEqualsInt(t, len(repaired.Intersections()), 9, "Wrong number of intersections")
+}
+
+func TestRepairFoldsCrossing(t *testing.T) {
+	mGrid := squareMorphGrid(64, 64, image.Point{32, 32})
+	_ = mGrid.RemovePoints(1, 1)
+	mGrid.AddPoints(1, 1, image.Point{32, 32}, image.Point{70, 32})
+	repaired, moves, err := mGrid.RepairFolds(RepairOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(foldProblems(repaired.Validate())), 0, "Repaired grid still folds")
+	for _, move := range moves {
+		if move.Side != GridDest {
+			t.Error("Only the destination grid should move", move)
+		}
+		LogVerbose(t, move)
+	}
+	if len(moves) == 0 {
+		t.Fatal("No points moved")
+	}
+	// The original is left untouched.
+	_, destPt, _ := mGrid.Points(1, 1)
+	AssertEqualsImagePoint(t, destPt, image.Point{70, 32}, "Original grid was modified")
+	// The least squares ordering splits the difference between the two points.
+	_, newCenter, _ := repaired.Float64Points(1, 1)
+	_, newRight, _ := repaired.Float64Points(1, 2)
+	if !(newCenter.X < newRight.X) || newCenter.X > 70 || newRight.X < 64 {
+		t.Error("Unexpected repair", newCenter, newRight)
+	}
+}
+
+func TestRepairFoldsCurveCrossing(t *testing.T) {
+	mGrid := curveCrossingGrid()
+	repaired, moves, err := mGrid.RepairFolds(RepairOptions{Validate: ValidateOptions{Times: []float64{}}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(foldProblems(repaired.ValidateWith(ValidateOptions{Times: []float64{}}))), 0, "Repaired grid still crosses")
+	if len(moves) == 0 {
+		t.Fatal("No points moved")
+	}
+}
+
+func TestRepairFoldsNonMonotone(t *testing.T) {
+	mGrid := NewMorphGrid()
+	pts := []image.Point{{0, 0}, {50, 5}, {0, 6}, {0, 40}}
+	for h, pt := range pts {
+		mGrid.AddPoints(h, 0, pt, pt)
+	}
+	if len(foldProblems(mGrid.Validate())) == 0 {
+		t.Fatal("Test grid should fold")
+	}
+	repaired, moves, err := mGrid.RepairFolds(RepairOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(foldProblems(repaired.Validate())), 0, "Repaired grid still folds")
+	for _, move := range moves {
+		AssertEqualsFloat64Slice(t, []float64{move.To.Y}, []float64{move.From.Y}, "Points should only move across the sweep axis")
+	}
+}
+
+func TestOrderLine(t *testing.T) {
+	pts := []Float64Point{{0, 0}, {0, 10}, {0, 6}, {0, 20}}
+	ordered := orderLine(pts, true, 1)
+	AssertEqualsFloat64Slice(t, []float64{ordered[0].Y, ordered[1].Y, ordered[2].Y, ordered[3].Y}, []float64{0, 7.5, 8.5, 20}, "Wrong ordering")
+}
@@ -0,0 +1,144 @@
+package gorph
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SVGOptions controls how a MorphGrid is exported as SVG.
+type SVGOptions struct {
+	// Bounds is the area of the image covered by the document. If empty, the
+	// reference bounds of the grid are used, or else the bounds of the background.
+	Bounds image.Rectangle
+	// Background is embedded as a PNG beneath the grid, if not nil.
+	Background image.Image
+	// Steps is the number of points sampled along every spline. Defaults to 64.
+	Steps int
+	// Tolerance, if positive, samples every spline adaptively instead, with as many
+	// points as it takes for the path to stay within Tolerance of the spline.
+	Tolerance float64
+	// Curves, if true, writes every spline exactly as cubic Bezier curves with
+	// CubicSpline.ToSVGPath instead of sampling it.
+	Curves bool
+	// PointRadius is the radius of the circle drawn at every intersection. Defaults
+	// to 3.
+	PointRadius float64
+}
+
+// svgLayerColors are the stroke colors of the start and destination layers.
+var svgLayerColors = [2]string{"#d62728", "#1f77b4"}
+
+// WriteSVG exports the grid as an SVG document for review. The start and
+// destination grids are drawn as separate layers, which are groups with the ids
+// "start" and "dest". Each layer holds the Catmull-Rom splines of its vertical and
+// horizontal lines as paths, and a circle at every intersection labelled with its
+// horizontal and vertical line indices. A circle's id is the layer name followed by
+// the indices, such as "start-h3-v5", which is understood by ReadSVG.
+func (m *MorphGrid) WriteSVG(w io.Writer, opts SVGOptions) error {
+	bounds := opts.Bounds
+	if bounds.Empty() {
+		if reference, ok := m.ReferenceBounds(); ok {
+			bounds = reference
+		} else if opts.Background != nil {
+			bounds = opts.Background.Bounds()
+		}
+	}
+	if bounds.Empty() {
+		return errors.New("WriteSVG: No bounds given, and the grid has neither reference bounds nor a background")
+	}
+	steps := opts.Steps
+	if steps <= 0 {
+		steps = 64
+	}
+	radius := opts.PointRadius
+	if radius <= 0 {
+		radius = 3
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" viewBox=\"%d %d %d %d\" width=\"%d\" height=\"%d\">\n", bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy(), bounds.Dx(), bounds.Dy())
+	if opts.Background != nil {
+		var buf bytes.Buffer
+		err := png.Encode(&buf, opts.Background)
+		if err != nil {
+			return err
+		}
+		bgBounds := opts.Background.Bounds()
+		fmt.Fprintf(bw, "\t<image id=\"background\" x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" href=\"data:image/png;base64,%s\"/>\n", bgBounds.Min.X, bgBounds.Min.Y, bgBounds.Dx(), bgBounds.Dy(), base64.StdEncoding.EncodeToString(buf.Bytes()))
+	}
+	layerNames := [2]string{"start", "dest"}
+	for i, grid := range []*float64CoordinateGrid{m.start, m.dest} {
+		name := layerNames[i]
+		fmt.Fprintf(bw, "\t<g id=\"%s\" stroke=\"%s\">\n", name, svgLayerColors[i])
+		for _, vertical := range []bool{true, false} {
+			if opts.Curves {
+				curves, err := grid.allCubicSplines(vertical, m.style)
+				if err != nil {
+					return err
+				}
+				for _, curve := range curves {
+					fmt.Fprintf(bw, "\t\t<path fill=\"none\" d=\"%s\"/>\n", curve.ToSVGPath())
+				}
+				continue
+			}
+			var splines []*parametricLineFloat64
+			var err error
+			if opts.Tolerance > 0 {
+				splines, err = grid.allAdaptiveSplines(vertical, m.style, opts.Tolerance)
+			} else {
+				splines, _, err = grid.allSplines(vertical, m.style, steps)
+			}
+			if err != nil {
+				return err
+			}
+			for _, spline := range splines {
+				fmt.Fprintf(bw, "\t\t<path fill=\"none\" d=\"%s\"/>\n", svgPolylineData(spline.parametricPoints))
+			}
+		}
+		for _, index := range grid.intersections() {
+			pt, err := grid.point(index.HorizLine, index.VertLine)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(bw, "\t\t<circle id=\"%s-h%d-v%d\" cx=\"%s\" cy=\"%s\" r=\"%s\" fill=\"%s\"/>\n", name, index.HorizLine, index.VertLine, svgNumber(pt.X), svgNumber(pt.Y), svgNumber(radius), svgLayerColors[i])
+			fmt.Fprintf(bw, "\t\t<text x=\"%s\" y=\"%s\" font-size=\"%s\" stroke=\"none\" fill=\"%s\">%d,%d</text>\n", svgNumber(pt.X+radius), svgNumber(pt.Y-radius), svgNumber(4*radius), svgLayerColors[i], index.HorizLine, index.VertLine)
+		}
+		fmt.Fprintf(bw, "\t</g>\n")
+	}
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// svgPolylineData formats points as the data of an SVG path of straight segments.
+func svgPolylineData(pts []Float64Point) string {
+	var sb strings.Builder
+	for i, pt := range pts {
+		if i == 0 {
+			sb.WriteString("M")
+		} else {
+			sb.WriteString(" L")
+		}
+		sb.WriteString(svgNumber(pt.X))
+		sb.WriteString(" ")
+		sb.WriteString(svgNumber(pt.Y))
+	}
+	return sb.String()
+}
+
+// svgNumber formats a number with at most three decimal places.
+func svgNumber(value float64) string {
+	value = math.Round(value*1000) / 1000
+	if value == 0 {
+		value = 0 // Avoid writing negative zero
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,70 @@
+package gorph
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestMorphGridWriteSVG(t *testing.T) {
+	width := 8
+	height := 8
+	mGrid := squareMorphGrid(width, height, image.Point{5, 5})
+	var buf bytes.Buffer
+	err := mGrid.WriteSVG(&buf, SVGOptions{Background: image.NewRGBA64(image.Rect(0, 0, width, height)), Steps: 8})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	doc := buf.String()
+	LogVerbose(t, doc)
+	for _, expected := range []string{"<g id=\"start\"", "<g id=\"dest\"", "id=\"start-h1-v1\" cx=\"4\" cy=\"4\"", "id=\"dest-h1-v1\" cx=\"5\" cy=\"5\"", ">1,1</text>", "<image id=\"background\"", "data:image/png;base64,"} {
+		if !strings.Contains(doc, expected) {
+			t.Error("SVG is missing " + expected)
+		}
+	}
+	AssertEqualsInt(t, strings.Count(doc, "<path "), 12, "Number of spline paths incorrect")
+	AssertEqualsInt(t, strings.Count(doc, "<circle "), 18, "Number of circles incorrect")
+}
+
+func TestMorphGridWriteSVGNoBounds(t *testing.T) {
+	var buf bytes.Buffer
+	err := squareMorphGrid(8, 8, image.Point{5, 5}).WriteSVG(&buf, SVGOptions{})
+	if err == nil {
+		t.Error("Expected error when no bounds are available")
+	}
+}
+
+func TestMorphGridWriteSVGTolerance(t *testing.T) {
+	mGrid := squareMorphGrid(64, 64, image.Point{40, 40})
+	var coarse, fine bytes.Buffer
+	err := mGrid.WriteSVG(&coarse, SVGOptions{Bounds: image.Rect(0, 0, 64, 64), Tolerance: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = mGrid.WriteSVG(&fine, SVGOptions{Bounds: image.Rect(0, 0, 64, 64), Tolerance: 0.01})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, strings.Count(fine.String(), "<path "), 12, "Number of spline paths incorrect")
+	if strings.Count(fine.String(), " L") <= strings.Count(coarse.String(), " L") {
+		t.Error("A finer tolerance should sample more points")
+	}
+}
+
+func TestMorphGridWriteSVGCurves(t *testing.T) {
+	mGrid := squareMorphGrid(64, 64, image.Point{40, 40})
+	var buf bytes.Buffer
+	err := mGrid.WriteSVG(&buf, SVGOptions{Bounds: image.Rect(0, 0, 64, 64), Curves: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, strings.Count(buf.String(), "<path "), 12, "Number of spline paths incorrect")
+	if strings.Contains(buf.String(), " L") || !strings.Contains(buf.String(), " C") {
+		t.Error("Splines were not written as Bezier curves")
+	}
+	_, _, err = ReadSVG(&buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
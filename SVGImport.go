@@ -0,0 +1,410 @@
+package gorph
+
+import (
+	"encoding/xml"
+	"errors"
+	"image"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FeatureLine is a polyline marking a feature of an image, such as the edge of an
+// eyebrow, for feature-based morphing.
+type FeatureLine []Float64Point
+
+// FeatureLinePair holds homogulous feature lines on the start and destination
+// images, along with the id pairing them.
+type FeatureLinePair struct {
+	ID    string
+	Start FeatureLine
+	Dest  FeatureLine
+}
+
+// svgPointID matches the id of a control point, optionally prefixed by its layer.
+var svgPointID = regexp.MustCompile(`^(?:(?:start|dest)-)?h(\d+)-v(\d+)$`)
+
+// svgTransformFunction matches one function of a transform attribute along with its
+// arguments.
+var svgTransformFunction = regexp.MustCompile(`^[\s,]*(matrix|translate|scale|rotate|skewX|skewY)\s*\(([^)]*)\)`)
+
+// svgPathTolerance is the distance in pixels within which the curves of a path are
+// flattened into a feature line.
+const svgPathTolerance = 0.1
+
+// svgScope is the layer an SVG element is found in, along with the transform from
+// its coordinates to those of the document.
+type svgScope struct {
+	layer     svgLayer
+	transform Affine
+}
+
+// svgLayer is the layer an SVG element is found in.
+type svgLayer int
+
+const (
+	svgNoLayer svgLayer = iota
+	svgStartLayer
+	svgDestLayer
+)
+
+// svgControlPoint is a control point read from a layer, along with the line of the
+// document it was read from.
+type svgControlPoint struct {
+	pt   Float64Point
+	line int
+}
+
+// svgFeatureLine is a feature line read from a layer, along with the line of the
+// document it was read from.
+type svgFeatureLine struct {
+	points FeatureLine
+	line   int
+}
+
+type svgImport struct {
+	decoder    *xml.Decoder
+	startPts   map[GridIndex]svgControlPoint
+	destPts    map[GridIndex]svgControlPoint
+	startLines map[string]svgFeatureLine
+	destLines  map[string]svgFeatureLine
+	reference  image.Rectangle
+}
+
+// ReadSVG reads control points and feature lines from a constrained subset of SVG,
+// such as documents written by WriteSVG or authored in a vector editor. Elements are
+// read from two layers, which are groups whose id or inkscape:label is "start" or
+// "dest". Within a layer:
+//
+//   - circle and ellipse elements are control points, located at their centers. Their
+//     ids name the grid intersection, such as "h3-v5" for horizontal line 3 and
+//     vertical line 5, optionally prefixed by the layer as in "start-h3-v5".
+//   - line, polyline and path elements are feature lines. Their ids pair the lines
+//     of the two layers, and may likewise be prefixed by the layer. A path must hold
+//     a single subpath, and its curves are flattened to within svgPathTolerance.
+//     Paths without ids, such as the grid lines drawn by WriteSVG, are ignored.
+//
+// Every other element, and every element outside of the layers, is ignored. The
+// transform attributes of the elements and the groups around them, such as the
+// translate a vector editor adds to a moved layer, are applied to the points read;
+// matrix, translate, scale, rotate, skewX and skewY are understood.
+//
+// The control points are returned as a MorphGrid and the feature lines as pairs
+// sorted by id. The viewBox of the document, or else its width and height when they
+// are plain numbers of pixels, become the reference bounds of the grid, as written
+// by WriteSVG. Returns an error, with the line of the document, for malformed ids
+// or transforms, duplicates, or points and lines without a counterpart in the other
+// layer.
+func ReadSVG(r io.Reader) (*MorphGrid, []FeatureLinePair, error) {
+	s := &svgImport{xml.NewDecoder(r), make(map[GridIndex]svgControlPoint), make(map[GridIndex]svgControlPoint), make(map[string]svgFeatureLine), make(map[string]svgFeatureLine), image.Rectangle{}}
+	var scopes []svgScope
+	for {
+		token, err := s.decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, errors.New("ReadSVG: " + err.Error())
+		}
+		switch el := token.(type) {
+		case xml.StartElement:
+			scope := svgScope{svgNoLayer, IdentityAffine()}
+			if len(scopes) > 0 {
+				scope = scopes[len(scopes)-1]
+			}
+			if el.Name.Local == "svg" && len(scopes) == 0 {
+				s.reference = svgDocumentBounds(el)
+			} else if el.Name.Local == "g" {
+				switch svgLayerName(el) {
+				case "start":
+					scope.layer = svgStartLayer
+				case "dest":
+					scope.layer = svgDestLayer
+				}
+			}
+			transform, err := parseSVGTransform(svgAttr(el, "transform"))
+			if err != nil {
+				return nil, nil, s.errorf(el.Name.Local + " has a malformed transform: " + err.Error())
+			}
+			scope.transform = scope.transform.Compose(transform)
+			scopes = append(scopes, scope)
+			if scope.layer != svgNoLayer {
+				err = s.readElement(el, scope)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+		case xml.EndElement:
+			scopes = scopes[:len(scopes)-1]
+		}
+	}
+	return s.results()
+}
+
+// svgDocumentBounds reads the bounds of a document from the viewBox of its svg
+// element, or else from its width and height. Returns an empty rectangle if neither
+// is given as plain numbers.
+func svgDocumentBounds(el xml.StartElement) image.Rectangle {
+	fields := strings.FieldsFunc(svgAttr(el, "viewBox"), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if len(fields) == 4 {
+		var box [4]float64
+		for i, field := range fields {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return image.Rectangle{}
+			}
+			box[i] = value
+		}
+		return Float64Rect{Float64Point{box[0], box[1]}, Float64Point{box[0] + box[2], box[1] + box[3]}}.ToRectangle(RoundNearest)
+	}
+	width, errWidth := strconv.ParseFloat(strings.TrimSuffix(svgAttr(el, "width"), "px"), 64)
+	height, errHeight := strconv.ParseFloat(strings.TrimSuffix(svgAttr(el, "height"), "px"), 64)
+	if errWidth != nil || errHeight != nil {
+		return image.Rectangle{}
+	}
+	return Float64Rect{Float64Point{0, 0}, Float64Point{width, height}}.ToRectangle(RoundNearest)
+}
+
+func svgLayerName(el xml.StartElement) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == "label" && (attr.Value == "start" || attr.Value == "dest") {
+			return attr.Value
+		}
+	}
+	return svgAttr(el, "id")
+}
+
+func svgAttr(el xml.StartElement, name string) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == name && attr.Name.Space == "" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// parseSVGTransform converts the value of a transform attribute to an Affine. An
+// empty value is the identity.
+func parseSVGTransform(value string) (Affine, error) {
+	result := IdentityAffine()
+	rest := strings.TrimSpace(value)
+	for rest != "" {
+		match := svgTransformFunction.FindStringSubmatch(rest)
+		if match == nil {
+			return Affine{}, errors.New("unknown transform \"" + rest + "\"")
+		}
+		rest = strings.TrimSpace(rest[len(match[0]):])
+		var args []float64
+		for _, field := range strings.FieldsFunc(match[2], func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		}) {
+			arg, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return Affine{}, errors.New("malformed number \"" + field + "\" in " + match[1])
+			}
+			args = append(args, arg)
+		}
+		var a Affine
+		switch {
+		case match[1] == "matrix" && len(args) == 6:
+			a = Affine{args[0], args[2], args[4], args[1], args[3], args[5]}
+		case match[1] == "translate" && (len(args) == 1 || len(args) == 2):
+			args = append(args, 0)
+			a = TranslateAffine(args[0], args[1])
+		case match[1] == "scale" && (len(args) == 1 || len(args) == 2):
+			args = append(args, args[0])
+			a = ScaleAffine(args[0], args[1])
+		case match[1] == "rotate" && len(args) == 1:
+			a = RotateAffine(args[0] * math.Pi / 180)
+		case match[1] == "rotate" && len(args) == 3:
+			a = RotateAboutAffine(args[0]*math.Pi/180, Float64Point{args[1], args[2]})
+		case match[1] == "skewX" && len(args) == 1:
+			a = ShearAffine(math.Tan(args[0]*math.Pi/180), 0)
+		case match[1] == "skewY" && len(args) == 1:
+			a = ShearAffine(0, math.Tan(args[0]*math.Pi/180))
+		default:
+			return Affine{}, errors.New(match[1] + " has " + strconv.Itoa(len(args)) + " arguments")
+		}
+		// Functions later in the list apply first
+		result = result.Compose(a)
+	}
+	return result, nil
+}
+
+func (s *svgImport) errorf(message string) error {
+	line, _ := s.decoder.InputPos()
+	return svgLineError(line, message)
+}
+
+func svgLineError(line int, message string) error {
+	return errors.New("ReadSVG: line " + strconv.Itoa(line) + ": " + message)
+}
+
+func (s *svgImport) readElement(el xml.StartElement, scope svgScope) error {
+	layer := scope.layer
+	id := svgAttr(el, "id")
+	switch el.Name.Local {
+	case "circle", "ellipse":
+		match := svgPointID.FindStringSubmatch(id)
+		if match == nil {
+			return s.errorf("malformed control point id \"" + id + "\", expected the form \"h3-v5\"")
+		}
+		horizLine, err := strconv.Atoi(match[1])
+		if err != nil {
+			return s.errorf("malformed control point id \"" + id + "\": " + err.Error())
+		}
+		vertLine, err := strconv.Atoi(match[2])
+		if err != nil {
+			return s.errorf("malformed control point id \"" + id + "\": " + err.Error())
+		}
+		if horizLine > maxDecodedLineIndex || vertLine > maxDecodedLineIndex {
+			return s.errorf("line index of control point \"" + id + "\" exceeds " + strconv.Itoa(maxDecodedLineIndex))
+		}
+		pt, err := s.parsePoint(el, "cx", "cy")
+		if err != nil {
+			return err
+		}
+		pt = scope.transform.Apply(pt)
+		pts := s.startPts
+		if layer == svgDestLayer {
+			pts = s.destPts
+		}
+		index := GridIndex{horizLine, vertLine}
+		if _, ok := pts[index]; ok {
+			return s.errorf("duplicate control point \"" + id + "\"")
+		}
+		line, _ := s.decoder.InputPos()
+		pts[index] = svgControlPoint{pt, line}
+	case "line", "polyline", "path":
+		name := strings.TrimPrefix(strings.TrimPrefix(id, "start-"), "dest-")
+		if name == "" && el.Name.Local == "path" {
+			// Paths without ids are the drawn grid lines of WriteSVG
+			return nil
+		} else if name == "" {
+			return s.errorf("feature line has no id")
+		}
+		var line FeatureLine
+		if el.Name.Local == "line" {
+			p1, err := s.parsePoint(el, "x1", "y1")
+			if err != nil {
+				return err
+			}
+			p2, err := s.parsePoint(el, "x2", "y2")
+			if err != nil {
+				return err
+			}
+			line = FeatureLine{p1, p2}
+		} else if el.Name.Local == "path" {
+			splines, err := ParseSVGPath(svgAttr(el, "d"))
+			if err != nil {
+				return s.errorf("path \"" + id + "\": " + err.Error())
+			}
+			if len(splines) != 1 {
+				return s.errorf("path \"" + id + "\" must have a single subpath")
+			}
+			line, err = AdaptiveSample(splines[0], svgPathTolerance)
+			if err != nil {
+				return s.errorf("path \"" + id + "\": " + err.Error())
+			}
+		} else {
+			fields := strings.FieldsFunc(svgAttr(el, "points"), func(r rune) bool {
+				return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+			})
+			if len(fields) < 4 || len(fields)%2 != 0 {
+				return s.errorf("polyline \"" + id + "\" must have two or more points")
+			}
+			for i := 0; i < len(fields); i += 2 {
+				x, errX := strconv.ParseFloat(fields[i], 64)
+				y, errY := strconv.ParseFloat(fields[i+1], 64)
+				if errX != nil || errY != nil {
+					return s.errorf("polyline \"" + id + "\" has a malformed point")
+				}
+				line = append(line, Float64Point{x, y})
+			}
+		}
+		for i := range line {
+			line[i] = scope.transform.Apply(line[i])
+		}
+		lines := s.startLines
+		if layer == svgDestLayer {
+			lines = s.destLines
+		}
+		if _, ok := lines[name]; ok {
+			return s.errorf("duplicate feature line \"" + name + "\"")
+		}
+		docLine, _ := s.decoder.InputPos()
+		lines[name] = svgFeatureLine{line, docLine}
+	}
+	return nil
+}
+
+func (s *svgImport) parsePoint(el xml.StartElement, xName, yName string) (Float64Point, error) {
+	x, err := strconv.ParseFloat(svgAttr(el, xName), 64)
+	if err != nil {
+		return Float64Point{}, s.errorf(el.Name.Local + " has a malformed " + xName + " attribute")
+	}
+	y, err := strconv.ParseFloat(svgAttr(el, yName), 64)
+	if err != nil {
+		return Float64Point{}, s.errorf(el.Name.Local + " has a malformed " + yName + " attribute")
+	}
+	return Float64Point{x, y}, nil
+}
+
+// results pairs the control points and feature lines of the two layers. A point or
+// line without a counterpart is reported at the earliest line of the document
+// holding one.
+func (s *svgImport) results() (*MorphGrid, []FeatureLinePair, error) {
+	unmatched, unmatchedLine := "", 0
+	report := func(line int, message string) {
+		if unmatched == "" || line < unmatchedLine {
+			unmatched, unmatchedLine = message, line
+		}
+	}
+	indices := make([]GridIndex, 0, len(s.startPts))
+	for index, pt := range s.startPts {
+		if _, ok := s.destPts[index]; !ok {
+			report(pt.line, "control point h"+strconv.Itoa(index.HorizLine)+"-v"+strconv.Itoa(index.VertLine)+" has no counterpart in the dest layer")
+		}
+		indices = append(indices, index)
+	}
+	for index, pt := range s.destPts {
+		if _, ok := s.startPts[index]; !ok {
+			report(pt.line, "control point h"+strconv.Itoa(index.HorizLine)+"-v"+strconv.Itoa(index.VertLine)+" has no counterpart in the start layer")
+		}
+	}
+	pairs := make([]FeatureLinePair, 0, len(s.startLines))
+	for name, line := range s.startLines {
+		destLine, ok := s.destLines[name]
+		if !ok {
+			report(line.line, "feature line \""+name+"\" has no counterpart in the dest layer")
+		}
+		pairs = append(pairs, FeatureLinePair{name, line.points, destLine.points})
+	}
+	for name, line := range s.destLines {
+		if _, ok := s.startLines[name]; !ok {
+			report(line.line, "feature line \""+name+"\" has no counterpart in the start layer")
+		}
+	}
+	if unmatched != "" {
+		return nil, nil, svgLineError(unmatchedLine, unmatched)
+	}
+
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i].HorizLine < indices[j].HorizLine || (indices[i].HorizLine == indices[j].HorizLine && indices[i].VertLine < indices[j].VertLine)
+	})
+	mGrid := NewMorphGrid()
+	for _, index := range indices {
+		mGrid.AddFloat64Points(index.HorizLine, index.VertLine, s.startPts[index].pt, s.destPts[index].pt)
+	}
+	if !s.reference.Empty() {
+		mGrid.SetReferenceBounds(s.reference)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].ID < pairs[j].ID
+	})
+	return mGrid, pairs, nil
+}
@@ -0,0 +1,195 @@
+package gorph
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestReadSVGRoundTrip(t *testing.T) {
+	mGrid := squareMorphGrid(8, 8, image.Point{5, 5})
+	mGrid.SetReferenceBounds(image.Rect(-2, 1, 8, 8))
+	var buf bytes.Buffer
+	err := mGrid.WriteSVG(&buf, SVGOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	result, pairs, err := ReadSVG(&buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(pairs), 0, "Feature line count incorrect")
+	AssertEqualsInt(t, len(result.Intersections()), 9, "Intersection count incorrect")
+	source, dest, err := result.Points(1, 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsImagePoint(t, source, image.Point{4, 4})
+	AssertEqualsImagePoint(t, dest, image.Point{5, 5})
+	reference, ok := result.ReferenceBounds()
+	if !ok || !reference.Eq(image.Rect(-2, 1, 8, 8)) {
+		t.Error("Reference bounds not restored", reference)
+	}
+}
+
+func TestReadSVGDocumentSize(t *testing.T) {
+	doc := `<svg xmlns="http://www.w3.org/2000/svg" width="640px" height="480">
+	<g id="start"><circle id="h0-v0" cx="1" cy="2" r="1"/></g>
+	<g id="dest"><circle id="h0-v0" cx="1" cy="2" r="1"/></g>
+</svg>`
+	mGrid, _, err := ReadSVG(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	reference, ok := mGrid.ReferenceBounds()
+	if !ok || !reference.Eq(image.Rect(0, 0, 640, 480)) {
+		t.Error("Reference bounds not read from the document size", reference)
+	}
+	doc = strings.Replace(doc, `width="640px" height="480"`, `width="10cm" height="8cm"`, 1)
+	mGrid, _, err = ReadSVG(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, ok := mGrid.ReferenceBounds(); ok {
+		t.Error("Document size in other units should not give reference bounds")
+	}
+}
+
+func TestReadSVGFeatureLines(t *testing.T) {
+	doc := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape">
+	<rect x="0" y="0" width="10" height="10"/>
+	<g inkscape:label="start" id="layer1">
+		<circle id="h0-v1" cx="1.5" cy="2" r="1"/>
+		<line id="start-brow" x1="0" y1="0" x2="4" y2="1"/>
+		<polyline id="lip" points="0,5 2,6 4,5"/>
+		<text>ignored</text>
+	</g>
+	<g inkscape:label="dest" id="layer2">
+		<ellipse id="h0-v1" cx="2.5" cy="3" rx="1" ry="1"/>
+		<line id="dest-brow" x1="1" y1="0" x2="5" y2="2"/>
+		<polyline id="lip" points="0 6 2 7 4 6"/>
+	</g>
+</svg>`
+	mGrid, pairs, err := ReadSVG(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	source, dest, err := mGrid.Float64Points(0, 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64Point(t, source, Float64Point{1.5, 2})
+	AssertEqualsFloat64Point(t, dest, Float64Point{2.5, 3})
+	AssertEqualsInt(t, len(pairs), 2, "Feature line count incorrect")
+	if pairs[0].ID != "brow" || pairs[1].ID != "lip" {
+		t.Fatal("Feature line ids incorrect")
+	}
+	AssertEqualsFloat64Point(t, pairs[0].Dest[1], Float64Point{5, 2})
+	AssertEqualsInt(t, len(pairs[1].Start), 3, "Polyline length incorrect")
+	AssertEqualsFloat64Point(t, pairs[1].Dest[2], Float64Point{4, 6})
+}
+
+func TestReadSVGMalformedID(t *testing.T) {
+	doc := `<svg xmlns="http://www.w3.org/2000/svg">
+	<g id="start">
+		<circle id="h3v5" cx="1" cy="2" r="1"/>
+	</g>
+</svg>`
+	_, _, err := ReadSVG(strings.NewReader(doc))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Error("Expected malformed id error on line 3", err)
+	}
+}
+
+func TestReadSVGOversizedID(t *testing.T) {
+	doc := `<svg xmlns="http://www.w3.org/2000/svg">
+	<g id="start">
+		<circle id="h2000000000-v0" cx="1" cy="2" r="1"/>
+	</g>
+</svg>`
+	_, _, err := ReadSVG(strings.NewReader(doc))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Error("Expected oversized id error on line 3", err)
+	}
+}
+
+func TestReadSVGUnmatchedPair(t *testing.T) {
+	doc := `<svg xmlns="http://www.w3.org/2000/svg">
+	<g id="start"><circle id="h3-v5" cx="1" cy="2" r="1"/></g>
+	<g id="dest"><circle id="h3-v6" cx="1" cy="2" r="1"/></g>
+</svg>`
+	_, _, err := ReadSVG(strings.NewReader(doc))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Error("Expected unmatched pair error on line 2", err)
+	}
+	doc = `<svg xmlns="http://www.w3.org/2000/svg">
+	<g id="start"><line id="brow" x1="0" y1="0" x2="1" y2="1"/></g>
+	<g id="dest">
+		<line id="brow" x1="0" y1="0" x2="1" y2="1"/>
+		<line id="lip" x1="0" y1="0" x2="1" y2="1"/>
+	</g>
+</svg>`
+	_, _, err = ReadSVG(strings.NewReader(doc))
+	if err == nil || !strings.Contains(err.Error(), "line 5") {
+		t.Error("Expected unmatched feature line error on line 5", err)
+	}
+}
+
+func TestReadSVGPathFeatureLines(t *testing.T) {
+	doc := `<svg xmlns="http://www.w3.org/2000/svg">
+	<g id="start"><path id="jaw" d="M0 0 L4 0 L4 4"/></g>
+	<g id="dest"><path id="jaw" d="M0 0 Q4 0 4 4"/></g>
+</svg>`
+	_, pairs, err := ReadSVG(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(pairs), 1, "Feature line count incorrect")
+	AssertEqualsInt(t, len(pairs[0].Start), 3, "Straight path should keep its vertices")
+	AssertEqualsFloat64Point(t, pairs[0].Start[1], Float64Point{4, 0})
+	if len(pairs[0].Dest) <= 2 {
+		t.Error("Curved path was not flattened", pairs[0].Dest)
+	}
+	AssertEqualsFloat64Point(t, pairs[0].Dest[len(pairs[0].Dest)-1], Float64Point{4, 4})
+}
+
+func TestReadSVGTransforms(t *testing.T) {
+	doc := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape">
+	<g transform="matrix(1 0 0 1 100 0)">
+		<g inkscape:label="start" transform="translate(10, 20)">
+			<circle id="h0-v0" cx="1" cy="2" r="1" transform="scale(2)"/>
+			<line id="brow" x1="0" y1="0" x2="4" y2="0" transform="rotate(90)"/>
+		</g>
+	</g>
+	<g id="dest" transform="translate(-1) skewX(45)">
+		<circle id="h0-v0" cx="0" cy="3" r="1"/>
+		<polyline id="brow" points="0,0 4,0"/>
+	</g>
+</svg>`
+	mGrid, pairs, err := ReadSVG(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	source, dest, err := mGrid.Float64Points(0, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, source, Float64Point{112, 24}, 1e-9, "Start point not transformed")
+	AssertEqualsFloat64PointTolerance(t, dest, Float64Point{2, 3}, 1e-9, "Dest point not transformed")
+	AssertEqualsInt(t, len(pairs), 1, "Feature line count incorrect")
+	AssertEqualsFloat64PointTolerance(t, pairs[0].Start[1], Float64Point{110, 24}, 1e-9, "Start line not transformed")
+	AssertEqualsFloat64PointTolerance(t, pairs[0].Dest[1], Float64Point{3, 0}, 1e-9, "Dest line not transformed")
+}
+
+func TestReadSVGMalformedTransform(t *testing.T) {
+	doc := `<svg xmlns="http://www.w3.org/2000/svg">
+	<g id="start">
+		<circle id="h0-v0" cx="1" cy="2" r="1" transform="translate(1, 2, 3)"/>
+	</g>
+</svg>`
+	_, _, err := ReadSVG(strings.NewReader(doc))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Error("Expected malformed transform error on line 3", err)
+	}
+}
@@ -0,0 +1,230 @@
+package gorph
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// svgPathNumber matches a number at the start of SVG path data.
+var svgPathNumber = regexp.MustCompile(`^[+-]?(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?`)
+
+// ToSVGPath formats the spline as the data of an SVG path of cubic Bezier curves,
+// "M x y C x1 y1 x2 y2 x y ...", with one C command for every segment. Numbers are
+// rounded to three decimal places, as in WriteSVG.
+func (s *CubicSpline) ToSVGPath() string {
+	controls := s.BezierControls()
+	var sb strings.Builder
+	sb.WriteString("M")
+	sb.WriteString(svgNumber(controls[0].X))
+	sb.WriteString(" ")
+	sb.WriteString(svgNumber(controls[0].Y))
+	for i := 1; i < len(controls); i++ {
+		if i%3 == 1 {
+			sb.WriteString(" C")
+		} else {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(svgNumber(controls[i].X))
+		sb.WriteString(" ")
+		sb.WriteString(svgNumber(controls[i].Y))
+	}
+	return sb.String()
+}
+
+// ToSVGPath formats the polyline as the data of an SVG path of straight segments,
+// "M x y L x y ...".
+func (f FeatureLine) ToSVGPath() string {
+	return svgPolylineData(f)
+}
+
+// svgPathParser reads the commands and numbers of SVG path data in turn.
+type svgPathParser struct {
+	data string
+	pos  int
+}
+
+func (p *svgPathParser) skipSeparators() {
+	for p.pos < len(p.data) && strings.IndexByte(" \t\n\r\f,", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *svgPathParser) done() bool {
+	p.skipSeparators()
+	return p.pos >= len(p.data)
+}
+
+// hasNumber reports whether a number follows, rather than a command or the end of
+// the data.
+func (p *svgPathParser) hasNumber() bool {
+	p.skipSeparators()
+	return svgPathNumber.MatchString(p.data[p.pos:])
+}
+
+func (p *svgPathParser) number() (float64, error) {
+	p.skipSeparators()
+	match := svgPathNumber.FindString(p.data[p.pos:])
+	if match == "" {
+		return 0, errors.New("ParseSVGPath: Expected a number at offset " + strconv.Itoa(p.pos))
+	}
+	value, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0, errors.New("ParseSVGPath: Malformed number \"" + match + "\" at offset " + strconv.Itoa(p.pos))
+	}
+	p.pos += len(match)
+	return value, nil
+}
+
+// point reads a coordinate pair, offset by origin for relative commands.
+func (p *svgPathParser) point(origin Float64Point) (Float64Point, error) {
+	x, err := p.number()
+	if err != nil {
+		return Float64Point{}, err
+	}
+	y, err := p.number()
+	if err != nil {
+		return Float64Point{}, err
+	}
+	return Float64Point{origin.X + x, origin.Y + y}, nil
+}
+
+// ParseSVGPath converts the data of an SVG path, its d attribute, into one
+// CubicSpline for every subpath. The moveto (M), lineto (L), cubic Bezier (C),
+// quadratic Bezier (Q) and closepath (Z) commands are understood, in both their
+// absolute upper case and relative lower case forms, along with the implicit
+// repetition of a command by further coordinates. Straight segments and quadratic
+// curves become cubic segments tracing the same path, and a closepath adds a
+// straight segment back to the start of the subpath unless the path is already
+// there. A subpath without any segments is dropped. Returns an error for any other
+// command, or data that is malformed.
+func ParseSVGPath(d string) ([]*CubicSpline, error) {
+	p := &svgPathParser{d, 0}
+	var splines []*CubicSpline
+	var controls []Float64Point
+	var current, start Float64Point
+	finish := func() error {
+		if len(controls) > 1 {
+			spline, err := NewBezierSpline(controls)
+			if err != nil {
+				return errors.New("ParseSVGPath: " + err.Error())
+			}
+			splines = append(splines, spline)
+		}
+		controls = nil
+		return nil
+	}
+	// line appends a straight segment from the current point as a cubic segment
+	line := func(to Float64Point) {
+		controls = append(controls,
+			Float64Point{current.X + (to.X-current.X)/3, current.Y + (to.Y-current.Y)/3},
+			Float64Point{current.X + 2*(to.X-current.X)/3, current.Y + 2*(to.Y-current.Y)/3},
+			to)
+		current = to
+	}
+	if p.done() {
+		return nil, errors.New("ParseSVGPath: Path data is empty")
+	}
+	first := true
+	for !p.done() {
+		command := p.data[p.pos]
+		p.pos++
+		if first && command != 'M' && command != 'm' {
+			return nil, errors.New("ParseSVGPath: Path data must begin with a moveto command")
+		}
+		first = false
+		var origin Float64Point
+		if command >= 'a' && command <= 'z' {
+			origin = current
+		}
+		switch command {
+		case 'M', 'm':
+			err := finish()
+			if err != nil {
+				return nil, err
+			}
+			current, err = p.point(origin)
+			if err != nil {
+				return nil, err
+			}
+			start = current
+			controls = []Float64Point{current}
+			// Further coordinates are implicit lineto commands
+			for p.hasNumber() {
+				if command == 'm' {
+					origin = current
+				}
+				to, err := p.point(origin)
+				if err != nil {
+					return nil, err
+				}
+				line(to)
+			}
+			continue
+		case 'Z', 'z':
+			if len(controls) > 1 && current != start {
+				line(start)
+			}
+			err := finish()
+			if err != nil {
+				return nil, err
+			}
+			current = start
+			continue
+		}
+		if controls == nil {
+			// A subpath drawn after a closepath starts where the previous one began
+			start = current
+			controls = []Float64Point{current}
+		}
+		repeated := false
+		for !repeated || p.hasNumber() {
+			repeated = true
+			if command >= 'a' && command <= 'z' {
+				origin = current
+			}
+			switch command {
+			case 'L', 'l':
+				to, err := p.point(origin)
+				if err != nil {
+					return nil, err
+				}
+				line(to)
+			case 'C', 'c':
+				var pts [3]Float64Point
+				for i := range pts {
+					pt, err := p.point(origin)
+					if err != nil {
+						return nil, err
+					}
+					pts[i] = pt
+				}
+				controls = append(controls, pts[0], pts[1], pts[2])
+				current = pts[2]
+			case 'Q', 'q':
+				control, err := p.point(origin)
+				if err != nil {
+					return nil, err
+				}
+				to, err := p.point(origin)
+				if err != nil {
+					return nil, err
+				}
+				// Elevate the quadratic curve to a cubic one
+				controls = append(controls,
+					Float64Point{current.X + 2*(control.X-current.X)/3, current.Y + 2*(control.Y-current.Y)/3},
+					Float64Point{to.X + 2*(control.X-to.X)/3, to.Y + 2*(control.Y-to.Y)/3},
+					to)
+				current = to
+			default:
+				return nil, errors.New("ParseSVGPath: Unsupported command '" + string(command) + "' at offset " + strconv.Itoa(p.pos-1))
+			}
+		}
+	}
+	err := finish()
+	if err != nil {
+		return nil, err
+	}
+	return splines, nil
+}
@@ -0,0 +1,70 @@
+package gorph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCubicSplineToSVGPath(t *testing.T) {
+	spline, err := NewBezierSpline([]Float64Point{{0, 0}, {1, 2}, {3, 2}, {4, 0}, {5, -2}, {7.5, -2}, {8, 0}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if d := spline.ToSVGPath(); d != "M0 0 C1 2 3 2 4 0 C5 -2 7.5 -2 8 0" {
+		t.Error("Path data incorrect", d)
+	}
+	if d := (FeatureLine{{0, 0}, {1, 2}}).ToSVGPath(); d != "M0 0 L1 2" {
+		t.Error("Polyline path data incorrect", d)
+	}
+}
+
+func TestParseSVGPathRoundTrip(t *testing.T) {
+	spline, err := NewCatmullRomSpline([]Float64Point{{0, 0}, {10, 5}, {20, -5}, {30, 0}}, CatmullRomOptions{Alpha: 0.5})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	splines, err := ParseSVGPath(spline.ToSVGPath())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(splines), 1, "Subpath count incorrect")
+	AssertEqualsInt(t, splines[0].Segments(), spline.Segments(), "Segment count incorrect")
+	for i := 0; i <= 30; i++ {
+		u := float64(i) / 10
+		AssertEqualsFloat64PointTolerance(t, splines[0].At(u), spline.At(u), .001, "Parsed curve differs from the spline")
+	}
+}
+
+func TestParseSVGPathCommands(t *testing.T) {
+	splines, err := ParseSVGPath("m1,1 l3 0 0 3 Q 7 7 10 4 c1-1 2-1 3 0 z M20 20 L 24 20 L 24 24Z l1-1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(splines), 3, "Subpath count incorrect")
+	first := splines[0]
+	AssertEqualsInt(t, first.Segments(), 5, "Segment count incorrect")
+	AssertEqualsFloat64Point(t, first.At(1), Float64Point{4, 1}, "Relative lineto incorrect")
+	AssertEqualsFloat64Point(t, first.At(2), Float64Point{4, 4}, "Implicit lineto incorrect")
+	AssertEqualsFloat64Point(t, first.At(3), Float64Point{10, 4}, "Quadratic end point incorrect")
+	AssertEqualsFloat64Point(t, first.At(4), Float64Point{13, 4}, "Relative cubic end point incorrect")
+	AssertEqualsFloat64Point(t, first.At(5), Float64Point{1, 1}, "Closepath did not return to the start")
+	// The quadratic curve from (4, 4) through control (7, 7) to (10, 4) peaks midway at
+	// (7, 5.5).
+	AssertEqualsFloat64PointTolerance(t, first.At(2.5), Float64Point{7, 5.5}, .000001, "Quadratic curve incorrect")
+	AssertEqualsFloat64PointTolerance(t, first.At(0.5), Float64Point{2.5, 1}, .000001, "Straight segment incorrect")
+	AssertEqualsInt(t, splines[1].Segments(), 3, "Closed subpath segment count incorrect")
+	// A subpath drawn after a closepath starts at the start of the closed one.
+	AssertEqualsFloat64Point(t, splines[2].At(0), Float64Point{20, 20}, "Subpath after closepath starts incorrectly")
+	AssertEqualsFloat64Point(t, splines[2].At(1), Float64Point{21, 19}, "Subpath after closepath ends incorrectly")
+}
+
+func TestParseSVGPathErrors(t *testing.T) {
+	for _, d := range []string{"", "L 1 1", "M 1 1 L 2", "M 1 1 A 1 1 0 0 0 2 2", "M 1 1 L 2 x"} {
+		_, err := ParseSVGPath(d)
+		if err == nil {
+			t.Error("Expected error for path data", d)
+		} else if !strings.HasPrefix(err.Error(), "ParseSVGPath: ") {
+			t.Error("Error is not prefixed by the function name", err)
+		}
+	}
+}
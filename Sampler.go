@@ -0,0 +1,154 @@
+package gorph
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// BorderMode determines the color a Sampler uses for pixels that lie outside of an
+// image's bounds.
+type BorderMode int
+
+const (
+	// BorderTransparent treats pixels outside of the image as transparent black.
+	BorderTransparent BorderMode = iota
+	// BorderClamp repeats the nearest edge pixel of the image.
+	BorderClamp
+	// BorderWrap tiles the image, so pixels past one edge wrap around to the other.
+	BorderWrap
+	// BorderReflect mirrors the image across its edges.
+	BorderReflect
+)
+
+// Sampler computes the color of an image at a fractional pixel location. Locations
+// follow the same convention as Float64Point, so the center of the pixel given by
+// image.Point(x, y) lies at (x + 0.5, y + 0.5). Pixels outside of the image's bounds
+// are determined by the border mode.
+type Sampler func(img image.Image, pt Float64Point, border BorderMode) color.Color
+
+// NearestNeighborSampler samples the color of the pixel containing the location.
+func NearestNeighborSampler(img image.Image, pt Float64Point, border BorderMode) color.Color {
+	return borderPixel(img, int(math.Floor(pt.X)), int(math.Floor(pt.Y)), border)
+}
+
+// BilinearSampler samples a color by linearly interpolating between the centers of
+// the four pixels nearest the location.
+func BilinearSampler(img image.Image, pt Float64Point, border BorderMode) color.Color {
+	px := pt.X - 0.5
+	py := pt.Y - 0.5
+	x0 := math.Floor(px)
+	y0 := math.Floor(py)
+	fx := px - x0
+	fy := py - y0
+	var acc colorAccumulator
+	acc.add(borderPixel(img, int(x0), int(y0), border), (1-fx)*(1-fy))
+	acc.add(borderPixel(img, int(x0)+1, int(y0), border), fx*(1-fy))
+	acc.add(borderPixel(img, int(x0), int(y0)+1, border), (1-fx)*fy)
+	acc.add(borderPixel(img, int(x0)+1, int(y0)+1, border), fx*fy)
+	return acc.rgba64()
+}
+
+// BicubicSampler samples a color by cubic convolution over the sixteen pixels nearest
+// the location, using the Catmull-Rom kernel. It is sharper than BilinearSampler but
+// may slightly overshoot along hard edges.
+func BicubicSampler(img image.Image, pt Float64Point, border BorderMode) color.Color {
+	px := pt.X - 0.5
+	py := pt.Y - 0.5
+	x0 := math.Floor(px)
+	y0 := math.Floor(py)
+	fx := px - x0
+	fy := py - y0
+	var acc colorAccumulator
+	for j := -1; j <= 2; j++ {
+		wy := cubicConvolutionWeight(float64(j) - fy)
+		for i := -1; i <= 2; i++ {
+			wx := cubicConvolutionWeight(float64(i) - fx)
+			acc.add(borderPixel(img, int(x0)+i, int(y0)+j, border), wx*wy)
+		}
+	}
+	return acc.rgba64()
+}
+
+// cubicConvolutionWeight is Keys' cubic convolution kernel with a = -0.5.
+func cubicConvolutionWeight(dist float64) float64 {
+	dist = math.Abs(dist)
+	if dist < 1 {
+		return 1.5*dist*dist*dist - 2.5*dist*dist + 1
+	} else if dist < 2 {
+		return -0.5*dist*dist*dist + 2.5*dist*dist - 4*dist + 2
+	}
+	return 0
+}
+
+// borderPixel returns the color of a pixel, applying the border mode to pixels that
+// lie outside of the image's bounds.
+func borderPixel(img image.Image, x, y int, border BorderMode) color.Color {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return color.RGBA64{}
+	}
+	if (image.Point{x, y}).In(bounds) {
+		return img.At(x, y)
+	}
+	switch border {
+	case BorderClamp:
+		x = clampInt(x, bounds.Min.X, bounds.Max.X-1)
+		y = clampInt(y, bounds.Min.Y, bounds.Max.Y-1)
+	case BorderWrap:
+		x = bounds.Min.X + modInt(x-bounds.Min.X, bounds.Dx())
+		y = bounds.Min.Y + modInt(y-bounds.Min.Y, bounds.Dy())
+	case BorderReflect:
+		x = bounds.Min.X + reflectInt(x-bounds.Min.X, bounds.Dx())
+		y = bounds.Min.Y + reflectInt(y-bounds.Min.Y, bounds.Dy())
+	default:
+		return color.RGBA64{}
+	}
+	return img.At(x, y)
+}
+
+func clampInt(value, min, max int) int {
+	if value < min {
+		return min
+	} else if value > max {
+		return max
+	}
+	return value
+}
+
+func modInt(value, length int) int {
+	value %= length
+	if value < 0 {
+		value += length
+	}
+	return value
+}
+
+func reflectInt(value, length int) int {
+	value = modInt(value, 2*length)
+	if value >= length {
+		value = 2*length - 1 - value
+	}
+	return value
+}
+
+// colorAccumulator sums weighted premultiplied colors at full precision.
+type colorAccumulator struct {
+	r, g, b, a float64
+}
+
+func (c *colorAccumulator) add(col color.Color, weight float64) {
+	r, g, b, a := col.RGBA()
+	c.r += float64(r) * weight
+	c.g += float64(g) * weight
+	c.b += float64(b) * weight
+	c.a += float64(a) * weight
+}
+
+func (c *colorAccumulator) rgba64() color.RGBA64 {
+	a := math.Max(0, math.Min(0xffff, c.a))
+	r := math.Max(0, math.Min(a, c.r))
+	g := math.Max(0, math.Min(a, c.g))
+	b := math.Max(0, math.Min(a, c.b))
+	return color.RGBA64{uint16(r + 0.5), uint16(g + 0.5), uint16(b + 0.5), uint16(a + 0.5)}
+}
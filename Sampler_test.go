@@ -0,0 +1,45 @@
+package gorph
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func gradientImage(width, height int) *image.RGBA64 {
+	img := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	for i := 0; i < width; i++ {
+		for j := 0; j < height; j++ {
+			img.Set(i, j, color.RGBA64{uint16(0x1000 * i), uint16(0x1000 * j), 0, 0xffff})
+		}
+	}
+	return img
+}
+
+func TestNearestNeighborSampler(t *testing.T) {
+	img := gradientImage(4, 4)
+	AssertEqualsImageColor(t, NearestNeighborSampler(img, Float64Point{2.9, 1.1}, BorderTransparent), img.At(2, 1))
+}
+
+func TestBilinearSamplerPixelCenter(t *testing.T) {
+	img := gradientImage(4, 4)
+	AssertEqualsImageColor(t, BilinearSampler(img, Float64Point{2.5, 1.5}, BorderClamp), img.At(2, 1))
+}
+
+func TestBilinearSamplerBetweenPixels(t *testing.T) {
+	img := gradientImage(4, 4)
+	AssertEqualsImageColor(t, BilinearSampler(img, Float64Point{2, 2}, BorderClamp), color.RGBA64{0x1800, 0x1800, 0, 0xffff})
+}
+
+func TestBicubicSamplerPixelCenter(t *testing.T) {
+	img := gradientImage(4, 4)
+	AssertEqualsImageColor(t, BicubicSampler(img, Float64Point{1.5, 2.5}, BorderClamp), img.At(1, 2))
+}
+
+func TestBorderModes(t *testing.T) {
+	img := gradientImage(4, 4)
+	AssertEqualsImageColor(t, borderPixel(img, -1, 0, BorderTransparent), color.RGBA64{}, "Transparent")
+	AssertEqualsImageColor(t, borderPixel(img, -1, 5, BorderClamp), img.At(0, 3), "Clamp")
+	AssertEqualsImageColor(t, borderPixel(img, -1, 5, BorderWrap), img.At(3, 1), "Wrap")
+	AssertEqualsImageColor(t, borderPixel(img, -1, 5, BorderReflect), img.At(0, 2), "Reflect")
+}
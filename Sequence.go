@@ -0,0 +1,78 @@
+package gorph
+
+// SequenceOptions controls how the time fractions of a morph animation are ordered
+// and repeated. The zero value yields the intermediate frames only, played from the
+// start image to the destination image.
+type SequenceOptions struct {
+	// IncludeEndpoints adds the start (0.0) and destination (1.0) time fractions
+	// around the intermediate frames.
+	IncludeEndpoints bool
+	// Reverse plays the sequence from the destination towards the start.
+	Reverse bool
+	// PingPong appends the sequence played backwards so the animation loops. The
+	// first and last frames are not repeated at the turning points.
+	PingPong bool
+	// HoldStart repeats the first frame this many additional times. A negative
+	// hold counts as zero.
+	HoldStart int
+	// HoldEnd repeats the last frame this many additional times. A negative hold
+	// counts as zero.
+	HoldEnd int
+}
+
+// FrameTimes computes the time fractions for numMorphs evenly spaced intermediate
+// frames, such that frame i lies at i/(numMorphs+1), and arranges them according to
+// the sequence options.
+func FrameTimes(numMorphs int, opts SequenceOptions) []float64 {
+	times := make([]float64, 0, numMorphs+2)
+	if opts.IncludeEndpoints {
+		times = append(times, 0)
+	}
+	for i := 1; i <= numMorphs; i++ {
+		times = append(times, float64(i)/float64(numMorphs+1))
+	}
+	if opts.IncludeEndpoints {
+		times = append(times, 1)
+	}
+	return ArrangeTimes(times, opts)
+}
+
+// ArrangeTimes applies the Reverse, HoldStart, HoldEnd and PingPong sequence options
+// to an explicit list of time fractions, which need not be evenly spaced. The
+// IncludeEndpoints option is ignored, since the list is used as given. The holds
+// apply to the ends of the forward sequence, before it is mirrored for PingPong.
+func ArrangeTimes(times []float64, opts SequenceOptions) []float64 {
+	n := len(times)
+	forward := make([]float64, n)
+	for i := 0; i < n; i++ {
+		if opts.Reverse {
+			forward[i] = times[n-1-i]
+		} else {
+			forward[i] = times[i]
+		}
+	}
+	if n == 0 {
+		return forward
+	}
+	holdStart, holdEnd := opts.HoldStart, opts.HoldEnd
+	if holdStart < 0 {
+		holdStart = 0
+	}
+	if holdEnd < 0 {
+		holdEnd = 0
+	}
+	result := make([]float64, 0, 2*n+holdStart+holdEnd)
+	for i := 0; i < holdStart; i++ {
+		result = append(result, forward[0])
+	}
+	result = append(result, forward...)
+	for i := 0; i < holdEnd; i++ {
+		result = append(result, forward[n-1])
+	}
+	if opts.PingPong {
+		for i := n - 2; i >= 1; i-- {
+			result = append(result, forward[i])
+		}
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package gorph
+
+import (
+	"image"
+	"testing"
+)
+
+func AssertEqualsFloat64Slice(t *testing.T, vals1, vals2 []float64, message ...string) {
+	equal := len(vals1) == len(vals2)
+	for i := 0; equal && i < len(vals1); i++ {
+		equal = vals1[i] == vals2[i]
+	}
+	if !equal {
+		t.Fail()
+		if message != nil && testing.Verbose() {
+			t.Log(message, vals1, vals2)
+		} else if testing.Verbose() {
+			t.Log(vals1, vals2)
+		}
+	}
+}
+
+func TestFrameTimesDefault(t *testing.T) {
+	AssertEqualsFloat64Slice(t, FrameTimes(3, SequenceOptions{}), []float64{0.25, 0.5, 0.75})
+}
+
+func TestFrameTimesIncludeEndpoints(t *testing.T) {
+	AssertEqualsFloat64Slice(t, FrameTimes(3, SequenceOptions{IncludeEndpoints: true}), []float64{0, 0.25, 0.5, 0.75, 1})
+}
+
+func TestFrameTimesReverse(t *testing.T) {
+	AssertEqualsFloat64Slice(t, FrameTimes(3, SequenceOptions{Reverse: true}), []float64{0.75, 0.5, 0.25})
+}
+
+func TestFrameTimesPingPong(t *testing.T) {
+	opts := SequenceOptions{IncludeEndpoints: true, PingPong: true}
+	AssertEqualsFloat64Slice(t, FrameTimes(3, opts), []float64{0, 0.25, 0.5, 0.75, 1, 0.75, 0.5, 0.25})
+}
+
+func TestFrameTimesHold(t *testing.T) {
+	opts := SequenceOptions{IncludeEndpoints: true, HoldStart: 2, HoldEnd: 1}
+	AssertEqualsFloat64Slice(t, FrameTimes(1, opts), []float64{0, 0, 0, 0.5, 1, 1})
+}
+
+func TestArrangeTimesNonUniform(t *testing.T) {
+	opts := SequenceOptions{PingPong: true, HoldEnd: 1}
+	AssertEqualsFloat64Slice(t, ArrangeTimes([]float64{0, 0.1, 0.6, 1}, opts), []float64{0, 0.1, 0.6, 1, 1, 0.6, 0.1})
+}
+
+func TestArrangeTimesNegativeHold(t *testing.T) {
+	opts := SequenceOptions{HoldStart: -10, HoldEnd: -1}
+	AssertEqualsFloat64Slice(t, ArrangeTimes([]float64{0, 0.5, 1}, opts), []float64{0, 0.5, 1})
+	opts = SequenceOptions{HoldStart: -10, HoldEnd: 2}
+	AssertEqualsFloat64Slice(t, ArrangeTimes([]float64{0, 1}, opts), []float64{0, 1, 1, 1})
+}
+
+func TestMorphTimesEndpoints(t *testing.T) {
+	width := 8
+	height := 8
+	start := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	dest := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	mGrid := squareMorphGrid(width, height, image.Point{5, 5})
+	times := FrameTimes(1, SequenceOptions{IncludeEndpoints: true, PingPong: true})
+	results, err := MorphTimes(times, start, dest, *mGrid, LinearInterpolationImagePoints, func(t float64) float64 { return t })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(results), 4, "Number of frames incorrect")
+	if results[0] != image.Image(start) {
+		t.Error("First frame is not the start image")
+	}
+	if results[2] != image.Image(dest) {
+		t.Error("Turning frame is not the destination image")
+	}
+	if results[1] != results[3] {
+		t.Error("Repeated time did not reuse the same frame")
+	}
+}
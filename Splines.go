@@ -0,0 +1,158 @@
+package gorph
+
+import (
+	"errors"
+	"strconv"
+)
+
+// bezierSegment converts the four control points of a cubic Bezier curve to
+// polynomial coefficients.
+func bezierSegment(p0, c1, c2, p1 Float64Point) cubicSegment {
+	coefficients := func(v0, v1, v2, v3 float64) [4]float64 {
+		return [4]float64{v0, 3 * (v1 - v0), 3 * (v0 - 2*v1 + v2), -v0 + 3*v1 - 3*v2 + v3}
+	}
+	return cubicSegment{coefficients(p0.X, c1.X, c2.X, p1.X), coefficients(p0.Y, c1.Y, c2.Y, p1.Y)}
+}
+
+// NewBezierSpline joins cubic Bezier curves into a spline. The control points are
+// the first point followed by two handles and an end point for every segment, so
+// there must be 3n+1 of them for n segments.
+func NewBezierSpline(controls []Float64Point) (*CubicSpline, error) {
+	if len(controls) < 4 || (len(controls)-1)%3 != 0 {
+		return nil, errors.New("NewBezierSpline: Number of control points must be 3n+1, for n of 1 or greater")
+	}
+	nSegments := (len(controls) - 1) / 3
+	spline := &CubicSpline{points: make([]Float64Point, 0, nSegments+1), segments: make([]cubicSegment, 0, nSegments)}
+	spline.points = append(spline.points, controls[0])
+	for i := 0; i < nSegments; i++ {
+		p0, c1, c2, p1 := controls[3*i], controls[3*i+1], controls[3*i+2], controls[3*i+3]
+		spline.points = append(spline.points, p1)
+		spline.segments = append(spline.segments, bezierSegment(p0, c1, c2, p1))
+	}
+	return spline, nil
+}
+
+// BezierControls converts the spline into the control points of cubic Bezier
+// curves, as accepted by NewBezierSpline. Converting a Catmull-Rom spline this way
+// allows it to be drawn by tools that only understand Bezier curves.
+func (s *CubicSpline) BezierControls() []Float64Point {
+	controls := make([]Float64Point, 0, 3*len(s.segments)+1)
+	controls = append(controls, s.points[0])
+	for i, segment := range s.segments {
+		x, y := segment.x, segment.y
+		controls = append(controls,
+			Float64Point{x[0] + x[1]/3, y[0] + y[1]/3},
+			Float64Point{x[0] + 2*x[1]/3 + x[2]/3, y[0] + 2*y[1]/3 + y[2]/3},
+			s.points[i+1])
+	}
+	return controls
+}
+
+// CatmullRomToBezier converts the Catmull-Rom spline through a given set of points
+// into the control points of cubic Bezier curves.
+func CatmullRomToBezier(points []Float64Point, opts CatmullRomOptions) ([]Float64Point, error) {
+	spline, err := NewCatmullRomSpline(points, opts)
+	if err != nil {
+		return nil, err
+	}
+	return spline.BezierControls(), nil
+}
+
+// NewBSpline computes the uniform cubic B-spline of a given set of control points.
+// There must be at least four control points, making one segment for every control
+// point after the third. A B-spline is smoother than a Catmull-Rom spline, but only
+// approaches its control points rather than passing through them, except where a
+// point is repeated three times.
+func NewBSpline(controls []Float64Point) (*CubicSpline, error) {
+	if len(controls) < 4 {
+		return nil, errors.New("NewBSpline: Less than four control points passed in")
+	}
+	nSegments := len(controls) - 3
+	spline := &CubicSpline{points: make([]Float64Point, 0, nSegments+1), segments: make([]cubicSegment, 0, nSegments)}
+	coefficients := func(v0, v1, v2, v3 float64) [4]float64 {
+		return [4]float64{(v0 + 4*v1 + v2) / 6, (v2 - v0) / 2, (v0 - 2*v1 + v2) / 2, (-v0 + 3*v1 - 3*v2 + v3) / 6}
+	}
+	for i := 0; i < nSegments; i++ {
+		p0, p1, p2, p3 := controls[i], controls[i+1], controls[i+2], controls[i+3]
+		segment := cubicSegment{coefficients(p0.X, p1.X, p2.X, p3.X), coefficients(p0.Y, p1.Y, p2.Y, p3.Y)}
+		if i == 0 {
+			spline.points = append(spline.points, Float64Point{segment.x[0], segment.y[0]})
+		}
+		spline.points = append(spline.points, Float64Point{evalCubic(segment.x, 1), evalCubic(segment.y, 1)})
+		spline.segments = append(spline.segments, segment)
+	}
+	return spline, nil
+}
+
+// NewHermiteSpline computes the cubic Hermite spline through a given set of points
+// with the given tangent at every point. A tangent is the derivative of the spline
+// with respect to the parameter of its segments, so a tangent equal to the chord of
+// a segment moves along it at an even pace.
+func NewHermiteSpline(points, tangents []Float64Point) (*CubicSpline, error) {
+	if len(points) < 2 {
+		return nil, errors.New("NewHermiteSpline: Less than two points passed in")
+	}
+	if len(points) != len(tangents) {
+		return nil, errors.New("NewHermiteSpline: Number of tangents " + strconv.Itoa(len(tangents)) + " does not match the number of points " + strconv.Itoa(len(points)))
+	}
+	spline := &CubicSpline{points: append([]Float64Point(nil), points...), segments: make([]cubicSegment, 0, len(points)-1)}
+	for i := 0; i < len(points)-1; i++ {
+		spline.segments = append(spline.segments, hermiteSegment(points[i], points[i+1], tangents[i], tangents[i+1]))
+	}
+	return spline, nil
+}
+
+// KochanekBartels holds the parameters of a Kochanek-Bartels spline, each in the
+// range [-1, 1]. All zero gives a uniform Catmull-Rom spline.
+type KochanekBartels struct {
+	// Tension tightens the curve at its points when positive, and loosens it when
+	// negative.
+	Tension float64
+	// Bias leans the curve towards the segment after a point when positive, and
+	// towards the one before when negative.
+	Bias float64
+	// Continuity makes corners at the points when it strays from zero.
+	Continuity float64
+}
+
+// NewKochanekBartelsSpline computes the Kochanek-Bartels spline through a given set
+// of points, a Hermite spline whose tangents are shaped by tension, bias and
+// continuity. The phantom points beyond either end reflect their neighbors through
+// the end points.
+func NewKochanekBartelsSpline(points []Float64Point, params KochanekBartels) (*CubicSpline, error) {
+	nPoints := len(points)
+	if nPoints < 2 {
+		return nil, errors.New("NewKochanekBartelsSpline: Less than two points passed in")
+	}
+	extended := make([]Float64Point, 0, nPoints+2)
+	extended = append(extended, Float64Point{2*points[0].X - points[1].X, 2*points[0].Y - points[1].Y})
+	extended = append(extended, points...)
+	extended = append(extended, Float64Point{2*points[nPoints-1].X - points[nPoints-2].X, 2*points[nPoints-1].Y - points[nPoints-2].Y})
+	t, b, c := params.Tension, params.Bias, params.Continuity
+	// Tangents leaving and arriving at every point, which differ when the
+	// continuity is not zero
+	tangent := func(i int, before, after float64) Float64Point {
+		prev, pt, next := extended[i], extended[i+1], extended[i+2]
+		return Float64Point{before*(pt.X-prev.X) + after*(next.X-pt.X), before*(pt.Y-prev.Y) + after*(next.Y-pt.Y)}
+	}
+	spline := &CubicSpline{points: append([]Float64Point(nil), points...), segments: make([]cubicSegment, 0, nPoints-1)}
+	for i := 0; i < nPoints-1; i++ {
+		leaving := tangent(i, (1-t)*(1+b)*(1+c)/2, (1-t)*(1-b)*(1-c)/2)
+		arriving := tangent(i+1, (1-t)*(1+b)*(1-c)/2, (1-t)*(1-b)*(1+c)/2)
+		spline.segments = append(spline.segments, hermiteSegment(points[i], points[i+1], leaving, arriving))
+	}
+	return spline, nil
+}
+
+// Sample evaluates the spline at totSteps parameters spaced evenly from its first
+// point to its last. The total steps must be 2 or greater.
+func (s *CubicSpline) Sample(totSteps int) ([]Float64Point, error) {
+	if totSteps < 2 {
+		return nil, errors.New("Sample: Total steps must be 2 or greater")
+	}
+	pts := make([]Float64Point, totSteps)
+	for i := range pts {
+		pts[i] = s.At(s.End() * float64(i) / float64(totSteps-1))
+	}
+	return pts, nil
+}
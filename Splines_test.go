@@ -0,0 +1,131 @@
+package gorph
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestBezierSpline(t *testing.T) {
+	spline, err := NewBezierSpline([]Float64Point{{0, 0}, {0, 4}, {4, 4}, {4, 0}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, spline.At(0.5), Float64Point{2, 3}, .000001, "Incorrect midpoint")
+	AssertEqualsFloat64PointTolerance(t, spline.Derivative(0), Float64Point{0, 12}, .000001, "Incorrect start tangent")
+	_, err = NewBezierSpline([]Float64Point{{0, 0}, {0, 4}, {4, 4}})
+	if err == nil {
+		t.Error("Expected error for incomplete segment")
+	}
+}
+
+func TestCatmullRomToBezier(t *testing.T) {
+	points := []Float64Point{{0, 0}, {1, 3}, {4, 2}, {5, 5}}
+	opts := CatmullRomOptions{Alpha: 0.5}
+	controls, err := CatmullRomToBezier(points, opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(controls), 10, "Incorrect number of control points")
+	catmullRom, _ := NewCatmullRomSpline(points, opts)
+	bezier, err := NewBezierSpline(controls)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for i := 0; i <= 30; i++ {
+		param := float64(i) / 10
+		AssertEqualsFloat64PointTolerance(t, bezier.At(param), catmullRom.At(param), .000001, "Bezier differs from Catmull-Rom")
+	}
+}
+
+func TestBSpline(t *testing.T) {
+	spline, err := NewBSpline([]Float64Point{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, spline.Segments(), 2, "Incorrect number of segments")
+	AssertEqualsFloat64PointTolerance(t, spline.At(0), Float64Point{1, 0}, .000001, "Incorrect start")
+	AssertEqualsFloat64PointTolerance(t, spline.At(1.5), Float64Point{2.5, 0}, .000001, "Incorrect midpoint")
+	AssertEqualsFloat64PointTolerance(t, spline.At(2), Float64Point{3, 0}, .000001, "Incorrect end")
+
+	// The fitter clamps the ends to the first and last points
+	points := []Float64Point{{0, 0}, {5, 10}, {0, 20}}
+	fitted, err := BSplineFitter{}.Fit(points, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, fitted.At(0), points[0], .000001, "Fitted B-spline does not start at the first point")
+	AssertEqualsFloat64PointTolerance(t, fitted.At(fitted.End()), points[2], .000001, "Fitted B-spline does not end at the last point")
+	if mid := fitted.At(fitted.End() / 2); mid.X <= 0 || mid.X >= 5 {
+		t.Error("B-spline should approach but not reach the middle point", mid)
+	}
+}
+
+func TestHermiteSpline(t *testing.T) {
+	points := []Float64Point{{0, 0}, {2, 2}}
+	spline, err := NewHermiteSpline(points, []Float64Point{{2, 2}, {2, 2}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, spline.At(0.25), Float64Point{0.5, 0.5}, .000001, "Chord tangents should move evenly")
+	spline, err = NewHermiteSpline(points, []Float64Point{{0, 4}, {4, 0}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, spline.Derivative(0), Float64Point{0, 4}, .000001, "Incorrect start tangent")
+	AssertEqualsFloat64PointTolerance(t, spline.Derivative(1), Float64Point{4, 0}, .000001, "Incorrect end tangent")
+	_, err = NewHermiteSpline(points, []Float64Point{{1, 1}})
+	if err == nil {
+		t.Error("Expected error for missing tangent")
+	}
+}
+
+func TestKochanekBartelsSpline(t *testing.T) {
+	points := []Float64Point{{0, 0}, {1, 3}, {4, 2}, {5, 5}}
+	kb, err := NewKochanekBartelsSpline(points, KochanekBartels{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	uniform, err := NewCatmullRomSpline(points, CatmullRomOptions{Alpha: 0, Start: EndReflect, End: EndReflect})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for i := 0; i <= 30; i++ {
+		param := float64(i) / 10
+		AssertEqualsFloat64PointTolerance(t, kb.At(param), uniform.At(param), .000001, "Zero parameters should give a uniform Catmull-Rom spline")
+	}
+	tense, err := NewKochanekBartelsSpline(points, KochanekBartels{Tension: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, tense.Derivative(1), Float64Point{0, 0}, .000001, "Full tension should stop at the points")
+	corner, err := NewKochanekBartelsSpline(points, KochanekBartels{Continuity: -1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// With continuity -1 the tangents at a point follow the segment on either side
+	AssertEqualsFloat64PointTolerance(t, corner.Derivative(0.999999999), Float64Point{1, 3}, .00001, "Incorrect arriving tangent")
+	AssertEqualsFloat64PointTolerance(t, corner.Derivative(1), Float64Point{3, -1}, .000001, "Incorrect leaving tangent")
+}
+
+func TestMorphGridLineFitter(t *testing.T) {
+	mGrid := squareMorphGrid(8, 8, image.Point{5, 5})
+	if _, ok := mGrid.LineFitter().(CatmullRomFitter); !ok {
+		t.Error("Default fitter should be Catmull-Rom")
+	}
+	mGrid.SetLineFitter(KochanekBartelsFitter{KochanekBartels{Tension: 0.5}})
+	if _, ok := mGrid.LineFitter().(KochanekBartelsFitter); !ok {
+		t.Error("Fitter was not set")
+	}
+	AssertEqualsInt(t, len(mGrid.Validate()), 0, "Grid should be valid")
+	img := image.NewRGBA64(image.Rect(0, 0, 8, 8))
+	_, err := MorphFrame(0.5, img, img, *mGrid, LinearInterpolationImagePoints, func(t float64) float64 { return t })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var buf bytes.Buffer
+	err = mGrid.WriteSVG(&buf, SVGOptions{Bounds: image.Rect(0, 0, 8, 8), Steps: 8})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
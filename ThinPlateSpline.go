@@ -0,0 +1,149 @@
+package gorph
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// ThinPlateSpline is a smooth warp of the plane fitted to scattered landmarks. It
+// moves the start point of every LandmarkPair onto its destination point while
+// bending the plane as little as possible in between, so unlike a MorphGrid the
+// landmarks need no structure at all. It is the sum of an affine transform and one
+// radial basis function r^2 log r centered on every landmark.
+type ThinPlateSpline struct {
+	centers []Float64Point
+	weights []Float64Point
+	affine  Affine
+	norm    Affine
+}
+
+// NewThinPlateSpline fits the thin-plate spline moving the start point of every pair
+// onto its destination point. A regularization of 0 passes through the landmarks
+// exactly, and a larger one trades that for a smoother warp closer to the affine
+// transform that best fits the landmarks. The start points are moved to the origin
+// at an average distance of the square root of two while fitting, so the
+// regularization does not depend on the scale of the image. Returns an error if
+// there are fewer than three pairs, or the start points are degenerate, such as
+// all lying on a line or two of them coinciding.
+func NewThinPlateSpline(pairs []LandmarkPair, regularization float64) (*ThinPlateSpline, error) {
+	n := len(pairs)
+	if n < 3 {
+		return nil, errors.New("NewThinPlateSpline: Less than three landmark pairs passed in")
+	}
+	if regularization < 0 {
+		return nil, errors.New("NewThinPlateSpline: Regularization " + strconv.FormatFloat(regularization, 'g', -1, 64) + " must not be negative")
+	}
+	starts := make([]Float64Point, n)
+	for i, pair := range pairs {
+		starts[i] = pair.Start
+	}
+	norm, ok := normalizingAffine(starts)
+	if !ok {
+		return nil, errors.New("NewThinPlateSpline: Landmarks are degenerate")
+	}
+	centers := make([]Float64Point, n)
+	for i := range starts {
+		centers[i] = norm.Apply(starts[i])
+	}
+	// Solve the system [K+rI P; P^T 0][w; a] = [dest; 0], with K the basis function
+	// between every pair of centers, r the regularization and P the rows [1 x y] of
+	// the centers
+	size := n + 3
+	a := make([][]float64, size)
+	b := make([][2]float64, size)
+	for i := 0; i < n; i++ {
+		a[i] = make([]float64, size)
+		for j := 0; j < n; j++ {
+			a[i][j] = thinPlateBasis(centers[i].Sub(centers[j]))
+		}
+		a[i][i] += regularization
+		a[i][n], a[i][n+1], a[i][n+2] = 1, centers[i].X, centers[i].Y
+		b[i] = [2]float64{pairs[i].Dest.X, pairs[i].Dest.Y}
+	}
+	for k := 0; k < 3; k++ {
+		a[n+k] = make([]float64, size)
+		for j := 0; j < n; j++ {
+			a[n+k][j] = a[j][n+k]
+		}
+	}
+	if !solveLinearSystem(a, b) {
+		return nil, errors.New("NewThinPlateSpline: Landmarks are degenerate")
+	}
+	weights := make([]Float64Point, n)
+	for i := range weights {
+		weights[i] = Float64Point{b[i][0], b[i][1]}
+	}
+	affine := Affine{b[n+1][0], b[n+2][0], b[n][0], b[n+1][1], b[n+2][1], b[n][1]}
+	return &ThinPlateSpline{centers, weights, affine, norm}, nil
+}
+
+// Apply moves a point by the warp.
+func (t *ThinPlateSpline) Apply(pt Float64Point) Float64Point {
+	p := t.norm.Apply(pt)
+	result := t.affine.Apply(p)
+	for i, center := range t.centers {
+		result = result.Add(t.weights[i].Mul(thinPlateBasis(p.Sub(center))))
+	}
+	return result
+}
+
+// thinPlateBasis is the radial basis function r^2 log r of the offset from a center,
+// which is zero at the center itself.
+func thinPlateBasis(offset Float64Point) float64 {
+	r2 := offset.Dot(offset)
+	if r2 == 0 {
+		return 0
+	}
+	return r2 * math.Log(r2) / 2
+}
+
+// solveLinearSystem solves a x = b for both columns of b by Gaussian elimination
+// with partial pivoting, overwriting b with the solution and a with its
+// elimination. Returns false if a is singular.
+func solveLinearSystem(a [][]float64, b [][2]float64) bool {
+	const epsilon = 1e-12
+	n := len(a)
+	largest := 0.0
+	for i := range a {
+		for _, value := range a[i] {
+			largest = math.Max(largest, math.Abs(value))
+		}
+	}
+	if largest == 0 {
+		return false
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) <= epsilon*largest {
+			return false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for row := col + 1; row < n; row++ {
+			factor := a[row][col] / a[col][col]
+			if factor == 0 {
+				continue
+			}
+			for k := col; k < n; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+			b[row][0] -= factor * b[col][0]
+			b[row][1] -= factor * b[col][1]
+		}
+	}
+	for row := n - 1; row >= 0; row-- {
+		for k := row + 1; k < n; k++ {
+			b[row][0] -= a[row][k] * b[k][0]
+			b[row][1] -= a[row][k] * b[k][1]
+		}
+		b[row][0] /= a[row][row]
+		b[row][1] /= a[row][row]
+	}
+	return true
+}
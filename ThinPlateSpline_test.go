@@ -0,0 +1,73 @@
+package gorph
+
+import (
+	"testing"
+)
+
+func thinPlateTestPairs() []LandmarkPair {
+	pairs, _ := PairLandmarks(
+		[]Float64Point{{10, 10}, {90, 10}, {90, 70}, {10, 70}, {50, 40}, {30, 55}},
+		[]Float64Point{{12, 8}, {88, 14}, {95, 72}, {6, 66}, {55, 35}, {28, 60}},
+	)
+	return pairs
+}
+
+func TestThinPlateSplineInterpolates(t *testing.T) {
+	pairs := thinPlateTestPairs()
+	tps, err := NewThinPlateSpline(pairs, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, pair := range pairs {
+		AssertEqualsFloat64PointTolerance(t, tps.Apply(pair.Start), pair.Dest, 1e-6, "Landmark not moved onto its destination")
+	}
+}
+
+func TestThinPlateSplineAffine(t *testing.T) {
+	a := RotateAffine(0.3).Then(ScaleAffine(1.5, 0.75)).Then(TranslateAffine(4, -2))
+	starts := []Float64Point{{0, 0}, {100, 0}, {100, 80}, {0, 80}, {40, 30}}
+	pairs := make([]LandmarkPair, len(starts))
+	for i, start := range starts {
+		pairs[i] = LandmarkPair{start, a.Apply(start)}
+	}
+	tps, err := NewThinPlateSpline(pairs, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, pt := range []Float64Point{{-20, 5}, {50, 50}, {130, 90}} {
+		AssertEqualsFloat64PointTolerance(t, tps.Apply(pt), a.Apply(pt), 1e-6, "Affine landmarks should give an affine warp")
+	}
+}
+
+func TestThinPlateSplineRegularization(t *testing.T) {
+	pairs := thinPlateTestPairs()
+	tps, err := NewThinPlateSpline(pairs, 1e6)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// A heavily regularized spline no longer reaches its landmarks
+	if tps.Apply(pairs[4].Start).Eq(pairs[4].Dest, 0.5) {
+		t.Error("Regularized spline should not pass through the landmark")
+	}
+	_, err = NewThinPlateSpline(pairs, -1)
+	if err == nil {
+		t.Error("Expected error for negative regularization")
+	}
+}
+
+func TestThinPlateSplineDegenerate(t *testing.T) {
+	_, err := NewThinPlateSpline([]LandmarkPair{{Float64Point{0, 0}, Float64Point{1, 1}}, {Float64Point{1, 0}, Float64Point{2, 1}}}, 0)
+	if err == nil {
+		t.Error("Expected error for two landmark pairs")
+	}
+	collinear, _ := PairLandmarks([]Float64Point{{0, 0}, {1, 1}, {2, 2}, {3, 3}}, []Float64Point{{0, 0}, {1, 2}, {2, 1}, {3, 3}})
+	_, err = NewThinPlateSpline(collinear, 0)
+	if err == nil {
+		t.Error("Expected error for collinear landmarks")
+	}
+	coincident, _ := PairLandmarks([]Float64Point{{0, 0}, {5, 0}, {0, 5}, {5, 0}}, []Float64Point{{0, 0}, {5, 0}, {0, 5}, {6, 1}})
+	_, err = NewThinPlateSpline(coincident, 0)
+	if err == nil {
+		t.Error("Expected error for coincident landmarks")
+	}
+}
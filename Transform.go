@@ -0,0 +1,150 @@
+package gorph
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+// TransformBounds determines the bounds of an image produced by Transform.
+type TransformBounds int
+
+const (
+	// BoundsPreserve keeps the bounds of the original image, cropping whatever is
+	// moved outside of them.
+	BoundsPreserve TransformBounds = iota
+	// BoundsExpand grows or shrinks the bounds to the smallest rectangle holding the
+	// whole transformed image.
+	BoundsExpand
+)
+
+// TransformOptions controls how Transform resamples an image.
+type TransformOptions struct {
+	// Sampler computes the color of every resulting pixel from the original image.
+	// Defaults to BilinearSampler.
+	Sampler Sampler
+	// Border determines the color sampled from outside of the original image.
+	Border BorderMode
+	// Bounds selects whether the original bounds are kept or expanded to fit.
+	Bounds TransformBounds
+	// ThreeShear rotates the image with three shears, each stretching the rows or
+	// columns of the image as the passes of Morph do, instead of sampling it. Every
+	// pixel is weighted by its area, so the image is neither blurred nor aliased.
+	// The transform must be a rotation followed by a translation, and the sampler
+	// and border mode are not used.
+	ThreeShear bool
+}
+
+// RotateAboutAffine returns the transform that rotates about center by angle
+// radians, as Float64Point.Rotate does.
+func RotateAboutAffine(angle float64, center Float64Point) Affine {
+	return TranslateAffine(-center.X, -center.Y).Then(RotateAffine(angle)).Then(TranslateAffine(center.X, center.Y))
+}
+
+// Transform resamples an image by an affine transform, which may rotate, shear,
+// translate, scale or apply any other 2x3 matrix. Each location in the original
+// image is moved to a.Apply of that location, so every resulting pixel is sampled at
+// the inverse transform of its center. Returns an error if the image is empty or the
+// transform is singular.
+func Transform(img image.Image, a Affine, opts TransformOptions) (*image.RGBA64, error) {
+	srcBounds := img.Bounds()
+	if srcBounds.Empty() {
+		return nil, errors.New("Transform: Image bounds must not be empty")
+	}
+	inverse, err := a.Invert()
+	if err != nil {
+		return nil, errors.New("Transform: " + err.Error())
+	}
+	bounds := srcBounds
+	if opts.Bounds == BoundsExpand {
+		bounds = transformedBounds(a, srcBounds)
+	}
+	if opts.ThreeShear {
+		return threeShearRotate(img, a, bounds)
+	}
+	sampler := opts.Sampler
+	if sampler == nil {
+		sampler = BilinearSampler
+	}
+	result := image.NewRGBA64(bounds)
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			srcPt := inverse.Apply(Float64Point{float64(x) + 0.5, float64(y) + 0.5})
+			result.Set(x, y, sampler(img, srcPt, opts.Border))
+		}
+	}
+	return result, nil
+}
+
+// transformedBounds returns the smallest rectangle holding a transformed rectangle,
+// disregarding rounding error that would add a sliver of a pixel to an edge.
+func transformedBounds(a Affine, r image.Rectangle) image.Rectangle {
+	const epsilon = 1e-9
+	transformed := a.ApplyRect(ToFloat64Rect(r))
+	return Float64Rect{transformed.Min.Add(Float64Point{epsilon, epsilon}), transformed.Max.Sub(Float64Point{epsilon, epsilon})}.Enclosing()
+}
+
+// threeShearRotate applies a rotation and translation as a horizontal shear, a
+// vertical shear and another horizontal shear, writing the result within bounds.
+// Rotations beyond a quarter turn are first turned by a half turn, which moves
+// whole pixels.
+func threeShearRotate(img image.Image, a Affine, bounds image.Rectangle) (*image.RGBA64, error) {
+	const epsilon = 1e-9
+	if math.Abs(a[0]-a[4]) > epsilon || math.Abs(a[1]+a[3]) > epsilon || math.Abs(a.Det()-1) > epsilon {
+		return nil, errors.New("Transform: Three-shear rotation requires a transform of only a rotation and a translation")
+	}
+	angle := math.Atan2(a[3], a[0])
+	tx, ty := a[2], a[5]
+	if math.Abs(angle) > math.Pi/2 {
+		img = rotateHalfTurn(img)
+		angle -= math.Copysign(math.Pi, angle)
+	}
+	// The rotation is shearX(shear) shearY(sin) shearX(shear), and the translation
+	// is split between the vertical shear and the last horizontal one
+	shear := -math.Tan(angle / 2)
+	sin := math.Sin(angle)
+
+	first := shearImage(img, true, shear, 0, image.Rectangle{})
+	second := shearImage(first, false, sin, ty, image.Rectangle{})
+	return shearImage(second, true, shear, tx-shear*ty, bounds), nil
+}
+
+// shearImage shifts every row of an image horizontally, or every column vertically,
+// by k times the center of the row or column plus offset. The result has the given
+// bounds, or if they are empty, the bounds holding the whole sheared image.
+func shearImage(img image.Image, horizontally bool, k, offset float64, bounds image.Rectangle) *image.RGBA64 {
+	srcBounds := img.Bounds()
+	if bounds.Empty() {
+		shear := ShearAffine(0, k).Then(TranslateAffine(0, offset))
+		if horizontally {
+			shear = ShearAffine(k, 0).Then(TranslateAffine(offset, 0))
+		}
+		bounds = transformedBounds(shear, srcBounds)
+	}
+	result := image.NewRGBA64(bounds)
+	if horizontally {
+		for y := srcBounds.Min.Y; y < srcBounds.Max.Y; y++ {
+			shift := k*(float64(y)+0.5) + offset
+			mergePixelsInLine(true, y, false, false, float64(srcBounds.Min.X), float64(srcBounds.Max.X), float64(srcBounds.Min.X)+shift, float64(srcBounds.Max.X)+shift, img, result)
+		}
+	} else {
+		for x := srcBounds.Min.X; x < srcBounds.Max.X; x++ {
+			shift := k*(float64(x)+0.5) + offset
+			mergePixelsInLine(false, x, false, false, float64(srcBounds.Min.Y), float64(srcBounds.Max.Y), float64(srcBounds.Min.Y)+shift, float64(srcBounds.Max.Y)+shift, img, result)
+		}
+	}
+	return result
+}
+
+// rotateHalfTurn rotates an image by a half turn about the origin, so the pixel at
+// (x, y) moves to (-x-1, -y-1).
+func rotateHalfTurn(img image.Image) *image.RGBA64 {
+	srcBounds := img.Bounds()
+	result := image.NewRGBA64(image.Rect(-srcBounds.Max.X, -srcBounds.Max.Y, -srcBounds.Min.X, -srcBounds.Min.Y))
+	for x := srcBounds.Min.X; x < srcBounds.Max.X; x++ {
+		for y := srcBounds.Min.Y; y < srcBounds.Max.Y; y++ {
+			result.Set(-x-1, -y-1, img.At(x, y))
+		}
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package gorph
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestTransformTranslate(t *testing.T) {
+	img := gradientImage(6, 4)
+	result, err := Transform(img, TranslateAffine(2, 1), TransformOptions{Sampler: NearestNeighborSampler})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !result.Bounds().Eq(img.Bounds()) {
+		t.Error("Bounds were not preserved", result.Bounds())
+	}
+	AssertEqualsImageColor(t, result.At(3, 2), img.At(1, 1), "Pixel was not translated")
+	AssertEqualsImageColor(t, result.At(0, 0), color.RGBA64{}, "Uncovered pixel is not transparent")
+	clamped, err := Transform(img, TranslateAffine(2, 1), TransformOptions{Sampler: NearestNeighborSampler, Border: BorderClamp})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsImageColor(t, clamped.At(0, 0), img.At(0, 0), "Border mode was not used")
+}
+
+func TestTransformRotateExpand(t *testing.T) {
+	img := gradientImage(4, 2)
+	result, err := Transform(img, RotateAffine(math.Pi/2), TransformOptions{Sampler: NearestNeighborSampler, Bounds: BoundsExpand})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !result.Bounds().Eq(image.Rect(-2, 0, 0, 4)) {
+		t.Fatal("Expanded bounds incorrect", result.Bounds())
+	}
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 2; y++ {
+			AssertEqualsImageColor(t, result.At(-y-1, x), img.At(x, y), "Pixel was not rotated")
+		}
+	}
+	_, err = Transform(img, ScaleAffine(0, 1), TransformOptions{})
+	if err == nil {
+		t.Error("Expected error for a singular transform")
+	}
+}
+
+func TestTransformThreeShear(t *testing.T) {
+	bounds := image.Rect(0, 0, 10, 10)
+	img := image.NewRGBA64(bounds)
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			img.Set(x, y, color.RGBA64{0xffff, 0, 0, 0xffff})
+		}
+	}
+	result, err := Transform(img, RotateAboutAffine(math.Pi/6, Float64Point{5, 5}), TransformOptions{Bounds: BoundsExpand, ThreeShear: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsImageColor(t, result.At(5, 5), color.RGBA64{0xffff, 0, 0, 0xffff}, "Center of the rotated square is incorrect")
+	AssertEqualsImageColor(t, result.At(0, 0), color.RGBA64{}, "Rotated corner should be uncovered")
+	// Area weighting preserves the total coverage of the square.
+	coverage := 0.0
+	for x := result.Bounds().Min.X; x < result.Bounds().Max.X; x++ {
+		for y := result.Bounds().Min.Y; y < result.Bounds().Max.Y; y++ {
+			_, _, _, a := result.At(x, y).RGBA()
+			coverage += float64(a) / 0xffff
+		}
+	}
+	if math.Abs(coverage-100) > 2 {
+		t.Error("Rotation did not preserve the area of the square", coverage)
+	}
+}
+
+func TestTransformThreeShearHalfTurn(t *testing.T) {
+	img := gradientImage(6, 4)
+	result, err := Transform(img, RotateAboutAffine(math.Pi, Float64Point{3, 2}), TransformOptions{ThreeShear: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for x := 0; x < 6; x++ {
+		for y := 0; y < 4; y++ {
+			AssertEqualsImageColor(t, result.At(5-x, 3-y), img.At(x, y), "Pixel was not turned")
+		}
+	}
+	_, err = Transform(img, ScaleAffine(2, 2), TransformOptions{ThreeShear: true})
+	if err == nil {
+		t.Error("Expected error for a three-shear transform that is not a rotation")
+	}
+}
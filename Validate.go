@@ -0,0 +1,351 @@
+package gorph
+
+import (
+	"image"
+	"strconv"
+)
+
+// GridProblemKind classifies a problem found by MorphGrid.Validate.
+type GridProblemKind int
+
+const (
+	// ProblemNonMonotone marks a line whose spline folds back on itself along its
+	// sweep axis: a vertical line must be single-valued in y, and a horizontal line
+	// single-valued in x, for Morph to stretch pixels along it.
+	ProblemNonMonotone GridProblemKind = iota
+	// ProblemCrossingLines marks two neighboring lines that cross, either because
+	// their points are out of order along a perpendicular line, or because their
+	// splines meet between the points.
+	ProblemCrossingLines
+	// ProblemTooFewPoints marks a line with only one or two points. A spline needs
+	// three or more points, so Morph silently ignores such a line.
+	ProblemTooFewPoints
+	// ProblemMismatchedLineCount marks start and destination grids with a differing
+	// number of lines, or a line with a differing number of points.
+	ProblemMismatchedLineCount
+)
+
+// String returns a short name for the kind of problem.
+func (k GridProblemKind) String() string {
+	switch k {
+	case ProblemNonMonotone:
+		return "non-monotone line"
+	case ProblemCrossingLines:
+		return "crossing lines"
+	case ProblemTooFewPoints:
+		return "too few points"
+	case ProblemMismatchedLineCount:
+		return "mismatched line count"
+	}
+	return "unknown problem"
+}
+
+// GridSide identifies which grid of a MorphGrid a problem was found in.
+type GridSide int
+
+const (
+	// GridStart is the grid of the start image.
+	GridStart GridSide = iota
+	// GridDest is the grid of the destination image.
+	GridDest
+	// GridInterpolated is a grid interpolated between the two.
+	GridInterpolated
+)
+
+// String returns the name of the grid.
+func (s GridSide) String() string {
+	switch s {
+	case GridStart:
+		return "start"
+	case GridDest:
+		return "dest"
+	case GridInterpolated:
+		return "interpolated"
+	}
+	return "unknown"
+}
+
+// GridProblem describes a single problem found by MorphGrid.Validate.
+type GridProblem struct {
+	Kind GridProblemKind
+	// Side is the grid the problem was found in, and Time its time fraction: 0.0
+	// for the start grid and 1.0 for the destination grid.
+	Side GridSide
+	Time float64
+	// Vertical reports whether Line and OtherLine are vertical lines.
+	Vertical bool
+	// Line is the index of the offending line, or -1 for problems concerning the
+	// grid as a whole.
+	Line int
+	// OtherLine is the second line of a crossing, otherwise -1.
+	OtherLine int
+	// CrossLine is the perpendicular line at whose intersections a crossing was
+	// found, otherwise -1, as it is for splines meeting between their points.
+	CrossLine int
+	// Location is where a fold or crossing was found.
+	Location Float64Point
+	// Message describes the problem in a human readable form.
+	Message string
+}
+
+// String formats the problem along with the grid it was found in.
+func (p GridProblem) String() string {
+	grid := p.Side.String() + " grid"
+	if p.Side == GridInterpolated {
+		grid += " at t=" + strconv.FormatFloat(p.Time, 'g', 4, 64)
+	}
+	return grid + ": " + p.Message
+}
+
+// ValidateOptions configures MorphGrid.ValidateWith. The zero value uses the
+// defaults of MorphGrid.Validate.
+type ValidateOptions struct {
+	// Times are the time fractions of the interpolated grids to check. Defaults to
+	// 0.1, 0.2, ..., 0.9.
+	Times []float64
+	// TimeInterp interpolates the grids. Defaults to LinearInterpolationImagePoints.
+	TimeInterp InterpolationFunc
+	// Bounds are those of the images the grid will morph. A grid with reference
+	// bounds is rescaled to them before it is checked, as Morph does, so TimeInterp
+	// sees the same whole pixels and the problems are located in the rescaled
+	// coordinates. When empty, the grid is checked in its own coordinates, where a
+	// normalized grid has only the pixels 0 and 1, so they should be given for one
+	// unless TimeInterp is linear.
+	Bounds image.Rectangle
+}
+
+// Validate checks the start grid, the destination grid and the grids linearly
+// interpolated at the time fractions 0.1, 0.2, ..., 0.9 for problems that would make
+// Morph fail or silently ignore part of the grid. Every problem found is returned;
+// an empty list means the grid is valid.
+func (m *MorphGrid) Validate() []GridProblem {
+	return m.ValidateWith(ValidateOptions{})
+}
+
+// ValidateWith checks the grid like Validate, using the given options.
+func (m *MorphGrid) ValidateWith(opts ValidateOptions) []GridProblem {
+	times := opts.Times
+	if times == nil {
+		times = FrameTimes(9, SequenceOptions{})
+	}
+	timeInterp := opts.TimeInterp
+	if timeInterp == nil {
+		timeInterp = LinearInterpolationImagePoints
+	}
+	grid := m
+	if !opts.Bounds.Empty() {
+		if resolved, err := m.resolvedFor(opts.Bounds); err == nil {
+			grid = resolved
+		}
+	}
+	problems := grid.mismatchedLineCounts()
+	problems = append(problems, grid.start.problems(GridStart, 0, grid.style)...)
+	problems = append(problems, grid.dest.problems(GridDest, 1, grid.style)...)
+	for _, t := range times {
+		problems = append(problems, grid.interpolatedGrid(timeInterp, t).problems(GridInterpolated, t, grid.style)...)
+	}
+	return problems
+}
+
+func (m *MorphGrid) mismatchedLineCounts() []GridProblem {
+	var problems []GridProblem
+	if m.start.verticalGridlineCount() != m.dest.verticalGridlineCount() {
+		problems = append(problems, GridProblem{Kind: ProblemMismatchedLineCount, Side: GridDest, Time: 1, Vertical: true, Line: -1, OtherLine: -1, CrossLine: -1,
+			Message: "start has " + strconv.Itoa(m.start.verticalGridlineCount()) + " vertical lines but dest has " + strconv.Itoa(m.dest.verticalGridlineCount())})
+	}
+	if m.start.horizontalGridlineCount() != m.dest.horizontalGridlineCount() {
+		problems = append(problems, GridProblem{Kind: ProblemMismatchedLineCount, Side: GridDest, Time: 1, Vertical: false, Line: -1, OtherLine: -1, CrossLine: -1,
+			Message: "start has " + strconv.Itoa(m.start.horizontalGridlineCount()) + " horizontal lines but dest has " + strconv.Itoa(m.dest.horizontalGridlineCount())})
+	}
+	for _, vertical := range []bool{true, false} {
+		nLines := MaxInt(m.start.horizontalGridlineLen(), m.dest.horizontalGridlineLen())
+		if vertical {
+			nLines = MaxInt(m.start.verticalGridlineLen(), m.dest.verticalGridlineLen())
+		}
+		for i := 0; i < nLines; i++ {
+			sourcePts, destPts := m.HorizontalLineFloat64(i)
+			if vertical {
+				sourcePts, destPts = m.VerticalLineFloat64(i)
+			}
+			if len(sourcePts) != len(destPts) {
+				problems = append(problems, GridProblem{Kind: ProblemMismatchedLineCount, Side: GridDest, Time: 1, Vertical: vertical, Line: i, OtherLine: -1, CrossLine: -1,
+					Message: lineName(vertical, i) + " has " + strconv.Itoa(len(sourcePts)) + " points in start but " + strconv.Itoa(len(destPts)) + " in dest"})
+			}
+		}
+	}
+	return problems
+}
+
+// problems checks a single grid for lines with too few points, lines whose splines
+// are not monotone along their sweep axis, and crossing lines.
+func (f *float64CoordinateGrid) problems(side GridSide, t float64, style lineStyle) []GridProblem {
+	var problems []GridProblem
+	for _, vertical := range []bool{true, false} {
+		nLines := f.horizontalGridlineLen()
+		if vertical {
+			nLines = f.verticalGridlineLen()
+		}
+		for i := 0; i < nLines; i++ {
+			pts := f.horizontalLine(i)
+			if vertical {
+				pts = f.verticalLine(i)
+			}
+			if len(pts) == 0 {
+				continue
+			} else if len(pts) <= 2 {
+				problems = append(problems, GridProblem{Kind: ProblemTooFewPoints, Side: side, Time: t, Vertical: vertical, Line: i, OtherLine: -1, CrossLine: -1,
+					Message: lineName(vertical, i) + " has " + strconv.Itoa(len(pts)) + " points, but a spline needs 3 or more"})
+				continue
+			}
+			if location, folded := foldLocation(pts, vertical, style); folded {
+				problems = append(problems, GridProblem{Kind: ProblemNonMonotone, Side: side, Time: t, Vertical: vertical, Line: i, OtherLine: -1, CrossLine: -1, Location: location,
+					Message: lineName(vertical, i) + " folds back on itself near (" + strconv.FormatFloat(location.X, 'f', 2, 64) + ", " + strconv.FormatFloat(location.Y, 'f', 2, 64) + ")"})
+			}
+		}
+	}
+	for _, problem := range f.curveCrossings(style, f.crossings()) {
+		problem.Side = side
+		problem.Time = t
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// foldLocation computes the spline through the points of a line, the same curve the
+// mesh warp follows, and returns the first location where it turns back along its
+// sweep axis, if any. The derivative along the sweep axis is quadratic on every
+// segment, so it is checked at both ends of each segment and at its extremum.
+// Coincident consecutive points leave the spline without length and count as a
+// fold, as do points out of order along the sweep axis when the line model requires
+// them in order.
+func foldLocation(pts []Float64Point, vertical bool, style lineStyle) (Float64Point, bool) {
+	const epsilon = 1e-9
+	for i := 1; i < len(pts); i++ {
+		if pts[i] == pts[i-1] {
+			return pts[i], true
+		}
+		if style.fitter == nil && style.model == LineMonotoneCubic && ((vertical && pts[i].Y <= pts[i-1].Y) || (!vertical && pts[i].X <= pts[i-1].X)) {
+			return pts[i], true
+		}
+	}
+	spline, err := style.spline(pts, vertical)
+	if err != nil {
+		// The line cannot be drawn at all, which Morph fails on just the same
+		return pts[0], true
+	}
+	for i, segment := range spline.segments {
+		c := segment.x
+		if vertical {
+			c = segment.y
+		}
+		candidates := []float64{0, 1}
+		if c[3] != 0 {
+			if u := -c[2] / (3 * c[3]); u > 0 && u < 1 {
+				candidates = append(candidates, u)
+			}
+		}
+		for _, u := range candidates {
+			if evalCubicDerivative(c, u) < -epsilon {
+				return spline.At(float64(i) + u), true
+			}
+		}
+	}
+	return Float64Point{}, false
+}
+
+// crossings lists every pair of neighboring lines whose points are out of order
+// along a perpendicular line.
+func (f *float64CoordinateGrid) crossings() []GridProblem {
+	var problems []GridProblem
+	for _, vertical := range []bool{false, true} {
+		nCross := f.verticalGridlineLen()
+		nLines := f.horizontalGridlineLen()
+		if vertical {
+			nCross = f.horizontalGridlineLen()
+			nLines = f.verticalGridlineLen()
+		}
+		for c := 0; c < nCross; c++ {
+			prev := -1
+			var prevPt Float64Point
+			for i := 0; i < nLines; i++ {
+				pt, err := f.point(i, c)
+				if vertical {
+					pt, err = f.point(c, i)
+				}
+				if err != nil {
+					continue
+				}
+				if prev >= 0 && ((vertical && pt.X <= prevPt.X) || (!vertical && pt.Y <= prevPt.Y)) {
+					problems = append(problems, GridProblem{Kind: ProblemCrossingLines, Vertical: vertical, Line: prev, OtherLine: i, CrossLine: c, Location: pt,
+						Message: lineKind(vertical) + " lines " + strconv.Itoa(prev) + " and " + strconv.Itoa(i) + " cross at " + lineName(!vertical, c)})
+				}
+				prev = i
+				prevPt = pt
+			}
+		}
+	}
+	return problems
+}
+
+// curveCrossings adds to the crossings of points every pair of neighboring lines
+// whose splines, as followed by the mesh warp, meet between their points. Lines with
+// two or fewer points, which Morph ignores, and lines whose splines cannot be drawn
+// are passed over, as are pairs already crossing at their points.
+func (f *float64CoordinateGrid) curveCrossings(style lineStyle, crossings []GridProblem) []GridProblem {
+	type linePair struct {
+		vertical    bool
+		line, other int
+	}
+	reported := make(map[linePair]bool)
+	for _, problem := range crossings {
+		reported[linePair{problem.Vertical, problem.Line, problem.OtherLine}] = true
+	}
+	problems := crossings
+	for _, vertical := range []bool{false, true} {
+		nLines := f.horizontalGridlineLen()
+		if vertical {
+			nLines = f.verticalGridlineLen()
+		}
+		prev := -1
+		var prevMeasure *CurveMeasure
+		for i := 0; i < nLines; i++ {
+			pts := f.horizontalLine(i)
+			if vertical {
+				pts = f.verticalLine(i)
+			}
+			if len(pts) <= 2 {
+				continue
+			}
+			spline, err := style.spline(pts, vertical)
+			if err != nil {
+				prev = -1
+				continue
+			}
+			measure := NewCurveMeasure(spline)
+			if prev >= 0 && !reported[linePair{vertical, prev, i}] {
+				if intersections := measure.Intersections(prevMeasure); len(intersections) > 0 {
+					location := intersections[0].Point
+					problems = append(problems, GridProblem{Kind: ProblemCrossingLines, Vertical: vertical, Line: prev, OtherLine: i, CrossLine: -1, Location: location,
+						Message: lineKind(vertical) + " lines " + strconv.Itoa(prev) + " and " + strconv.Itoa(i) + " cross near (" + strconv.FormatFloat(location.X, 'f', 2, 64) + ", " + strconv.FormatFloat(location.Y, 'f', 2, 64) + ")"})
+				}
+			}
+			prev = i
+			prevMeasure = measure
+		}
+	}
+	return problems
+}
+
+func lineKind(vertical bool) string {
+	if vertical {
+		return "Vertical"
+	}
+	return "Horizontal"
+}
+
+func lineName(vertical bool, index int) string {
+	if vertical {
+		return "vertical line " + strconv.Itoa(index)
+	}
+	return "horizontal line " + strconv.Itoa(index)
+}
@@ -0,0 +1,192 @@
+package gorph
+
+import (
+	"image"
+	"strconv"
+	"testing"
+)
+
+func countProblems(problems []GridProblem, kind GridProblemKind, side GridSide) int {
+	n := 0
+	for _, p := range problems {
+		if p.Kind == kind && p.Side == side {
+			n++
+		}
+	}
+	return n
+}
+
+func TestValidateValidGrid(t *testing.T) {
+	mGrid := squareMorphGrid(64, 64, image.Point{40, 40})
+	problems := mGrid.Validate()
+	AssertEqualsInt(t, len(problems), 0, "Valid grid reported problems")
+	for _, p := range problems {
+		LogVerbose(t, p.String())
+	}
+}
+
+func TestValidateTooFewPoints(t *testing.T) {
+	mGrid := squareMorphGrid(64, 64, image.Point{32, 32})
+	mGrid.AddPoints(3, 0, image.Point{0, 96}, image.Point{0, 96})
+	problems := mGrid.Validate()
+	AssertEqualsInt(t, countProblems(problems, ProblemTooFewPoints, GridStart), 1, "Start short line not reported")
+	AssertEqualsInt(t, countProblems(problems, ProblemTooFewPoints, GridDest), 1, "Dest short line not reported")
+	for _, p := range problems {
+		if p.Kind == ProblemTooFewPoints && p.Side == GridStart {
+			AssertEqualsInt(t, p.Line, 3, "Wrong short line")
+			if p.Vertical {
+				t.Error("Short line should be horizontal")
+			}
+		}
+	}
+}
+
+func TestValidateCrossingLines(t *testing.T) {
+	mGrid := squareMorphGrid(64, 64, image.Point{32, 32})
+	_ = mGrid.RemovePoints(1, 1)
+	mGrid.AddPoints(1, 1, image.Point{32, 32}, image.Point{70, 32})
+	problems := mGrid.ValidateWith(ValidateOptions{Times: []float64{}})
+	AssertEqualsInt(t, countProblems(problems, ProblemCrossingLines, GridStart), 0, "Start grid should not cross")
+	AssertEqualsInt(t, countProblems(problems, ProblemCrossingLines, GridDest), 1, "Dest crossing not reported")
+	for _, p := range problems {
+		if p.Kind == ProblemCrossingLines {
+			if !p.Vertical {
+				t.Error("Crossing should be between vertical lines")
+			}
+			AssertEqualsInt(t, p.Line, 1, "Wrong first line")
+			AssertEqualsInt(t, p.OtherLine, 2, "Wrong second line")
+			AssertEqualsInt(t, p.CrossLine, 1, "Wrong crossing line")
+		}
+	}
+}
+
+// curveCrossingGrid has two vertical lines whose points are in order, but whose
+// splines meet between the points as the first bulges past the second.
+func curveCrossingGrid() *MorphGrid {
+	mGrid := NewMorphGrid()
+	for h, pt := range []Float64Point{{0, 0}, {0, 10}, {3, 12}, {3, 60}} {
+		mGrid.AddFloat64Points(h, 0, pt, pt)
+		mGrid.AddFloat64Points(h, 1, Float64Point{4, pt.Y}, Float64Point{4, pt.Y})
+	}
+	return mGrid
+}
+
+func TestValidateCurveCrossing(t *testing.T) {
+	problems := curveCrossingGrid().ValidateWith(ValidateOptions{Times: []float64{}})
+	AssertEqualsInt(t, countProblems(problems, ProblemCrossingLines, GridStart), 1, "Crossing between points not reported")
+	for _, p := range problems {
+		if p.Kind == ProblemCrossingLines && p.Side == GridStart {
+			if !p.Vertical {
+				t.Error("Crossing should be between vertical lines")
+			}
+			AssertEqualsInt(t, p.Line, 0, "Wrong first line")
+			AssertEqualsInt(t, p.OtherLine, 1, "Wrong second line")
+			AssertEqualsInt(t, p.CrossLine, -1, "Crossing between points has no crossing line")
+			if p.Location.X < 3.99 || p.Location.X > 4.01 || p.Location.Y < 12 || p.Location.Y > 60 {
+				t.Error("Crossing located incorrectly", p.Location)
+			}
+		}
+	}
+}
+
+func TestValidateNonMonotone(t *testing.T) {
+	mGrid := NewMorphGrid()
+	pts := []image.Point{{0, 0}, {10, 40}, {0, 20}, {10, 60}}
+	for h, pt := range pts {
+		mGrid.AddPoints(h, 0, pt, pt)
+	}
+	problems := mGrid.ValidateWith(ValidateOptions{Times: []float64{0.5}})
+	AssertEqualsInt(t, countProblems(problems, ProblemNonMonotone, GridStart), 1, "Start fold not reported")
+	AssertEqualsInt(t, countProblems(problems, ProblemNonMonotone, GridInterpolated), 1, "Interpolated fold not reported")
+}
+
+func TestValidateFoldOnWarpSpline(t *testing.T) {
+	mGrid := NewMorphGrid()
+	mGrid.SetLineFitter(KochanekBartelsFitter{KochanekBartels{Tension: -1}})
+	pts := []Float64Point{{0, 0}, {0, 30}, {0, 33}, {0, 60}}
+	for h, pt := range pts {
+		mGrid.AddFloat64Points(h, 0, pt, pt)
+	}
+	spline, err := mGrid.style.spline(pts, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// The loose spline overshoots the close middle points and runs back up between them
+	folded := false
+	for i := 0; i <= 3000; i++ {
+		if spline.Derivative(float64(i)/1000).Y < 0 {
+			folded = true
+		}
+	}
+	if !folded {
+		t.Fatal("Test spline should fold")
+	}
+	problems := mGrid.ValidateWith(ValidateOptions{Times: []float64{}})
+	AssertEqualsInt(t, countProblems(problems, ProblemNonMonotone, GridStart), 1, "Fold of the warp spline not reported")
+	for _, p := range problems {
+		if p.Kind == ProblemNonMonotone && p.Side == GridStart {
+			AssertEqualsInt(t, p.Line, 0, "Wrong folded line")
+			if p.Location.Y < 30 || p.Location.Y > 33 {
+				t.Error("Fold located outside of the middle segment: " + strconv.FormatFloat(p.Location.Y, 'g', -1, 64))
+			}
+		}
+	}
+}
+
+func TestValidateInterpolatedOnly(t *testing.T) {
+	mGrid := NewMorphGrid()
+	for h := 0; h < 3; h++ {
+		for v := 0; v < 3; v++ {
+			start := image.Point{v * 32, h * 32}
+			dest := start
+			if h == 1 {
+				// Swap the first and last columns, so midway all three coincide.
+				dest = image.Point{(2 - v) * 32, h * 32}
+			}
+			mGrid.AddPoints(h, v, start, dest)
+		}
+	}
+	problems := mGrid.ValidateWith(ValidateOptions{Times: []float64{0.5}})
+	AssertEqualsInt(t, countProblems(problems, ProblemCrossingLines, GridInterpolated), 2, "Interpolated crossing not reported")
+	for _, p := range problems {
+		if p.Side == GridInterpolated {
+			AssertEqualsFloat64Slice(t, []float64{p.Time}, []float64{0.5}, "Wrong time")
+		}
+	}
+}
+
+// pushMiddleColumn interpolates linearly, except that it pushes the middle column of
+// a 64 pixel wide squareMorphGrid across its right column midway.
+func pushMiddleColumn(start, end image.Point, t float64) Float64Point {
+	pt := LinearInterpolationImagePoints(start, end, t)
+	if start.X == 32 {
+		pt.X += 160 * t * (1 - t)
+	}
+	return pt
+}
+
+func TestValidateNormalizedGridBounds(t *testing.T) {
+	pixelGrid := squareMorphGrid(64, 64, image.Point{32, 32})
+	opts := ValidateOptions{Times: []float64{0.5}, TimeInterp: pushMiddleColumn}
+	expected := countProblems(pixelGrid.ValidateWith(opts), ProblemCrossingLines, GridInterpolated)
+	if expected == 0 {
+		t.Fatal("Pushed column does not cross")
+	}
+	normalGrid := NewNormalizedMorphGrid()
+	normalize := ScaleAffine(1.0/64, 1.0/64)
+	for _, index := range pixelGrid.Intersections() {
+		startPt, destPt, _ := pixelGrid.Float64Points(index.HorizLine, index.VertLine)
+		normalGrid.AddFloat64Points(index.HorizLine, index.VertLine, normalize.Apply(startPt), normalize.Apply(destPt))
+	}
+	// Rounded to 0 or 1 in its own coordinates, no point is on the pushed column
+	AssertEqualsInt(t, countProblems(normalGrid.ValidateWith(opts), ProblemCrossingLines, GridInterpolated), 0, "Normalized grid checked in pixels")
+	opts.Bounds = image.Rect(0, 0, 64, 64)
+	AssertEqualsInt(t, countProblems(normalGrid.ValidateWith(opts), ProblemCrossingLines, GridInterpolated), expected, "Normalized grid not rescaled to the bounds")
+}
+
+func TestValidateMismatchedLineCount(t *testing.T) {
+	mGrid := squareMorphGrid(64, 64, image.Point{32, 32})
+	_ = mGrid.dest.removePoint(2, 2)
+	problems := mGrid.Validate()
+	AssertEqualsInt(t, countProblems(problems, ProblemMismatchedLineCount, GridDest), 2, "Mismatched point counts not reported")
+}
@@ -3,6 +3,7 @@ package gorph
 import (
 	"errors"
 	"image"
+	"strconv"
 )
 
 type float64CoordinateGrid struct {
@@ -112,6 +113,37 @@ func (f *float64CoordinateGrid) checkBounds(horizLine, vertLine int) error {
 	return nil
 }
 
+func (f *float64CoordinateGrid) intersections() []GridIndex {
+	var indices []GridIndex
+	for h := 0; h < f.horizontalGridlineLen(); h++ {
+		for v := 0; v < f.verticalGridlineLen(); v++ {
+			if _, err := f.point(h, v); err == nil {
+				indices = append(indices, GridIndex{h, v})
+			}
+		}
+	}
+	return indices
+}
+
+func (f *float64CoordinateGrid) equals(other *float64CoordinateGrid) bool {
+	indices := f.intersections()
+	otherIndices := other.intersections()
+	if len(indices) != len(otherIndices) {
+		return false
+	}
+	for i, index := range indices {
+		if index != otherIndices[i] {
+			return false
+		}
+		pt, _ := f.point(index.HorizLine, index.VertLine)
+		otherPt, _ := other.point(index.HorizLine, index.VertLine)
+		if pt != otherPt {
+			return false
+		}
+	}
+	return true
+}
+
 func (f *float64CoordinateGrid) horizontalLine(index int) (source []Float64Point) {
 	source = make([]Float64Point, 0, f.verticalGridlineLen())
 	for vLine := 0; vLine < f.verticalGridlineLen(); vLine++ {
@@ -134,7 +166,19 @@ func (f *float64CoordinateGrid) verticalLine(index int) (source []Float64Point)
 	return
 }
 
-func (f *float64CoordinateGrid) allCubicCatmullRomSplines(vertical bool, alpha float64, totSteps int) (splines []*parametricLineFloat64, nSplines int, err error) {
+// checkCrossings returns an error if the points of any vertical line are not in
+// increasing y order, or the points of any horizontal line not in increasing x
+// order, both of which mean two grid lines cross.
+func (f *float64CoordinateGrid) checkCrossings() error {
+	if problems := f.crossings(); len(problems) > 0 {
+		return errors.New("checkCrossings: " + problems[0].Message)
+	}
+	return nil
+}
+
+// allSplines samples the curve of the given line style through every line with
+// more than two points.
+func (f *float64CoordinateGrid) allSplines(vertical bool, style lineStyle, totSteps int) (splines []*parametricLineFloat64, nSplines int, err error) {
 	splines = nil
 	nSplines = 0
 	err = nil
@@ -150,7 +194,7 @@ func (f *float64CoordinateGrid) allCubicCatmullRomSplines(vertical bool, alpha f
 			sourcePts = f.horizontalLine(i)
 		}
 		if len(sourcePts) > 2 {
-			sourceLine, err := CubicCatmullRomInterpolation(sourcePts, alpha, totSteps)
+			sourceLine, err := style.interpolate(sourcePts, vertical, totSteps)
 			if err != nil {
 				return nil, 0, err
 			}
@@ -161,3 +205,109 @@ func (f *float64CoordinateGrid) allCubicCatmullRomSplines(vertical bool, alpha f
 	}
 	return
 }
+
+// allAdaptiveSplines samples the curve of the given line style through every line
+// with more than two points, adaptively to within the tolerance.
+func (f *float64CoordinateGrid) allAdaptiveSplines(vertical bool, style lineStyle, tolerance float64) (splines []*parametricLineFloat64, err error) {
+	nLoops := f.horizontalGridlineLen()
+	if vertical {
+		nLoops = f.verticalGridlineLen()
+	}
+	for i := 0; i < nLoops; i++ {
+		sourcePts := f.horizontalLine(i)
+		if vertical {
+			sourcePts = f.verticalLine(i)
+		}
+		if len(sourcePts) > 2 {
+			spline, err := style.spline(sourcePts, vertical)
+			if err != nil {
+				return nil, err
+			}
+			sampled, err := AdaptiveSample(spline, tolerance)
+			if err != nil {
+				return nil, err
+			}
+			splines = append(splines, newParametricLineFloat64())
+			splines[len(splines)-1].AddPoints(sampled)
+		}
+	}
+	return splines, nil
+}
+
+// allCurves computes the analytic curve of the given line style through every line
+// with more than two points.
+func (f *float64CoordinateGrid) allCurves(vertical bool, style lineStyle) ([]axisCurve, error) {
+	splines, err := f.allCubicSplines(vertical, style)
+	if err != nil {
+		return nil, err
+	}
+	curves := make([]axisCurve, len(splines))
+	for i, spline := range splines {
+		curves[i] = spline
+	}
+	return curves, nil
+}
+
+// allCubicSplines computes the spline of the given line style through every line
+// with more than two points.
+func (f *float64CoordinateGrid) allCubicSplines(vertical bool, style lineStyle) (splines []*CubicSpline, err error) {
+	nLoops := f.horizontalGridlineLen()
+	if vertical {
+		nLoops = f.verticalGridlineLen()
+	}
+	for i := 0; i < nLoops; i++ {
+		sourcePts := f.horizontalLine(i)
+		if vertical {
+			sourcePts = f.verticalLine(i)
+		}
+		if len(sourcePts) > 2 {
+			spline, err := style.spline(sourcePts, vertical)
+			if err != nil {
+				return nil, err
+			}
+			splines = append(splines, spline)
+		}
+	}
+	return splines, nil
+}
+
+// weightedAverageGrid computes the weighted average of grids that share the same
+// topology. The weights are normalized by their sum.
+func weightedAverageGrid(grids []*float64CoordinateGrid, weights []float64) (*float64CoordinateGrid, error) {
+	if len(grids) == 0 || len(grids) != len(weights) {
+		return nil, errors.New("weightedAverageGrid: Number of grids does not match the number of weights")
+	}
+	sumWeights := 0.0
+	for _, w := range weights {
+		sumWeights += w
+	}
+	if sumWeights == 0 {
+		return nil, errors.New("weightedAverageGrid: Weights sum to zero")
+	}
+	indices := grids[0].intersections()
+	for i := 1; i < len(grids); i++ {
+		otherIndices := grids[i].intersections()
+		if len(otherIndices) != len(indices) {
+			return nil, errors.New("weightedAverageGrid: Grid " + strconv.Itoa(i) + " does not have the same topology as grid 0")
+		}
+		for j := range indices {
+			if indices[j] != otherIndices[j] {
+				return nil, errors.New("weightedAverageGrid: Grid " + strconv.Itoa(i) + " does not have the same topology as grid 0")
+			}
+		}
+	}
+	average := newFloat64CoordinateGrid()
+	for _, index := range indices {
+		var avgPt Float64Point
+		for i := 0; i < len(grids); i++ {
+			pt, err := grids[i].point(index.HorizLine, index.VertLine)
+			if err != nil {
+				return nil, err
+			}
+			avgPt.X += pt.X * weights[i] / sumWeights
+			avgPt.Y += pt.Y * weights[i] / sumWeights
+		}
+		average.addPoint(index.HorizLine, index.VertLine, avgPt)
+	}
+	return average, nil
+}
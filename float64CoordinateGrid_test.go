@@ -87,3 +87,39 @@ func TestFloat64CoordinateGridRemovePointsVertical(t *testing.T) {
 	source = m.verticalLine(4)
 	AssertEqualsInt(t, len(source), 1, "After removal length incorrect")
 }
+
+func TestWeightedAverageGrid(t *testing.T) {
+	a := newFloat64CoordinateGrid()
+	a.addPoint(0, 0, Float64Point{0, 0})
+	a.addPoint(0, 1, Float64Point{4, 0})
+	b := newFloat64CoordinateGrid()
+	b.addPoint(0, 0, Float64Point{2, 2})
+	b.addPoint(0, 1, Float64Point{8, 4})
+	avg, err := weightedAverageGrid([]*float64CoordinateGrid{a, b}, []float64{3, 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pt, err := avg.point(0, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, pt, Float64Point{0.5, 0.5}, .000001)
+	pt, err = avg.point(0, 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64PointTolerance(t, pt, Float64Point{5, 1}, .000001)
+}
+
+func TestWeightedAverageGridTopologyMismatch(t *testing.T) {
+	a := newFloat64CoordinateGrid()
+	a.addPoint(0, 0, Float64Point{0, 0})
+	a.addPoint(0, 1, Float64Point{4, 0})
+	b := newFloat64CoordinateGrid()
+	b.addPoint(0, 0, Float64Point{2, 2})
+	b.addPoint(1, 1, Float64Point{8, 4})
+	_, err := weightedAverageGrid([]*float64CoordinateGrid{a, b}, []float64{1, 1})
+	if err == nil {
+		t.Fail()
+	}
+}
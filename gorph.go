@@ -2,16 +2,16 @@ package gorph
 
 import (
 	"errors"
-	"fmt"
 	"image"
 	"image/color"
 	"math"
+	"strconv"
 )
 
 // InterpolationFunc interpolates a Float64Point between two image points based on a
-// ratio distance from the starting point to the ending point, such that fractionFromStart
-// lies on the interval [0.0, 1.0]. The value 0.0 maps to the starting point, and 1.0 to
-// the end point.
+// ratio distance from the starting point to the ending point. The value 0.0 maps to
+// the starting point, and 1.0 to the end point. Values outside of [0.0, 1.0]
+// extrapolate beyond the starting or ending point.
 type InterpolationFunc func(start, end image.Point, fractionFromStart float64) Float64Point
 
 // Morph performes a keyframe-based morphing of two images in order to interpolate a new set
@@ -24,100 +24,134 @@ type InterpolationFunc func(start, end image.Point, fractionFromStart float64) F
 // timeInterp - function to use to interpolate cross-fading grids over time
 // nominalTimeConversion - function to covert actual time frame of grid to nominal time used
 // in cross fading. The parameter and returned value must lie in the range [0.0, 1.0]
+// The start and destination images must share the same bounds; images of different
+// sizes may first be passed through ConformImages. If mGrid has reference bounds that
+// differ from those of the images, it is rescaled to the images' bounds.
 func Morph(numMorphs int, start, dest image.Image, mGrid MorphGrid, timeInterp InterpolationFunc, nominalTimeConversion func(float64) float64) ([]image.Image, error) {
-	startBounds := start.Bounds()
-	destBounds := dest.Bounds()
-	if !startBounds.Min.Eq(destBounds.Min) || !startBounds.Max.Eq(destBounds.Max) {
-		return nil, errors.New("Morph: image bounds do not match")
-	}
-	// go?
-	results := make([]image.Image, 0, numMorphs)
-	for i := 1; i <= numMorphs; i++ {
-		baseTimeFrac := float64(i) / float64(numMorphs+1)
-		intermedGrid := mGrid.interpolatedGrid(timeInterp, baseTimeFrac)
-		auxGridSource := newFloat64CoordinateGrid()
-		auxGridDest := newFloat64CoordinateGrid()
-
-		// TODO: Factory creation function?
-		auxSourceImage := image.NewRGBA64(startBounds)
-		auxDestImage := image.NewRGBA64(destBounds)
-		intermedSourceImage := image.NewRGBA64(startBounds)
-		intermedDestImage := image.NewRGBA64(startBounds)
+	return MorphTimes(FrameTimes(numMorphs, SequenceOptions{}), start, dest, mGrid, timeInterp, nominalTimeConversion)
+}
 
-		// TODO: Do this somewhere else?
-		maxX := intermedGrid.verticalGridlineCount()
-		maxY := intermedGrid.horizontalGridlineCount()
-		for x := 0; x < maxX; x++ {
-			for y := 0; y < maxY; y++ {
-				auxYPt, err := intermedGrid.point(y, x)
-				if err != nil {
-					return nil, err
-				}
-				auxXSourcePt, auxXDestPt, err := mGrid.Points(y, x)
+// MorphTimes creates one morph image for every time fraction in times, in order. The
+// remaining parameters are the same as those of Morph. The times may be unevenly
+// spaced, repeated or lie outside of [0.0, 1.0] as described by MorphFrame, and may
+// be built with FrameTimes or ArrangeTimes. A time of exactly 0.0 or 1.0 yields the
+// start or destination image itself, and repeated times share the same image.
+func MorphTimes(times []float64, start, dest image.Image, mGrid MorphGrid, timeInterp InterpolationFunc, nominalTimeConversion func(float64) float64) ([]image.Image, error) {
+	if !start.Bounds().Eq(dest.Bounds()) {
+		return nil, errors.New("MorphTimes: image bounds do not match")
+	}
+	resolvedGrid, err := mGrid.resolvedFor(start.Bounds())
+	if err != nil {
+		return nil, err
+	}
+	results := make([]image.Image, 0, len(times))
+	frames := make(map[float64]image.Image)
+	for _, t := range times {
+		result, ok := frames[t]
+		if !ok {
+			switch t {
+			case 0:
+				result = start
+			case 1:
+				result = dest
+			default:
+				result, err = MorphFrame(t, start, dest, *resolvedGrid, timeInterp, nominalTimeConversion)
 				if err != nil {
 					return nil, err
 				}
-				auxGridSource.addPoint(y, x, Float64Point{float64(auxXSourcePt.X), auxYPt.Y})
-				auxGridDest.addPoint(y, x, Float64Point{float64(auxXDestPt.X), auxYPt.Y})
 			}
+			frames[t] = result
 		}
+		results = append(results, result)
+	}
+	return results, nil
+}
 
-		// Calculate Cubic Catmull-Rom spline equations for each vertical line in
-		//   both original (source, dest) and aux (source, dest) images
-		sourceOriginalSplines, destOriginalSplines, nSplinesGrid, err := mGrid.allCubicCatmullRomSplines(true, 0.5, startBounds.Max.Y-startBounds.Min.Y)
-		if err != nil {
-			return nil, err
-		}
-		sourceAuxSplines, nSplinesAuxSource, err := auxGridSource.allCubicCatmullRomSplines(true, 0.5, startBounds.Max.Y-startBounds.Min.Y)
-		if err != nil {
-			return nil, err
-		}
-		if nSplinesGrid != nSplinesAuxSource {
-			return nil, errors.New("Given MorphGrid and source auxilary grid do not have the same number of splines.")
-		}
-		destAuxSplines, nSplinesDestSource, err := auxGridDest.allCubicCatmullRomSplines(true, 0.5, startBounds.Max.Y-startBounds.Min.Y)
+// MorphFrame creates the single morph image found at the time fraction t between the
+// start and destination images. The parameters are the same as those of Morph. The
+// time fraction may lie outside of [0.0, 1.0] in order to extrapolate the geometry
+// beyond either image, which exaggerates the differences between the two grids for a
+// caricature effect. Only the geometry is extrapolated: the time given to
+// nominalTimeConversion is clamped to [0.0, 1.0], as are the resulting cross fading
+// weights. An extrapolated grid is checked with CheckCrossings before warping, and
+// the error is returned if its grid lines cross.
+func MorphFrame(t float64, start, dest image.Image, mGrid MorphGrid, timeInterp InterpolationFunc, nominalTimeConversion func(float64) float64) (image.Image, error) {
+	startBounds := start.Bounds()
+	destBounds := dest.Bounds()
+	if !startBounds.Min.Eq(destBounds.Min) || !startBounds.Max.Eq(destBounds.Max) {
+		return nil, errors.New("MorphFrame: image bounds do not match")
+	}
+	resolvedGrid, err := mGrid.resolvedFor(startBounds)
+	if err != nil {
+		return nil, err
+	}
+	intermedGrid := resolvedGrid.interpolatedGrid(timeInterp, t)
+	if t < 0 || t > 1 {
+		err = intermedGrid.checkCrossings()
 		if err != nil {
-			return nil, err
-		}
-		if nSplinesGrid != nSplinesDestSource {
-			return nil, errors.New("Given MorphGrid and destination auxilary grid do not have the same number of splines.")
+			return nil, errors.New("MorphFrame: extrapolated grid is invalid: " + err.Error())
 		}
+	}
+	intermedSourceImage, err := warpToGrid(start, resolvedGrid.start, intermedGrid, resolvedGrid.style)
+	if err != nil {
+		return nil, err
+	}
+	intermedDestImage, err := warpToGrid(dest, resolvedGrid.dest, intermedGrid, resolvedGrid.style)
+	if err != nil {
+		return nil, err
+	}
 
-		err = stretchPixelsHorizontally(startBounds.Min.Y, startBounds.Max.Y, sourceOriginalSplines, sourceAuxSplines, start, auxSourceImage)
-		if err != nil {
-			return nil, err
-		}
-		err = stretchPixelsHorizontally(startBounds.Min.Y, startBounds.Max.Y, destOriginalSplines, destAuxSplines, dest, auxDestImage)
-		if err != nil {
-			return nil, err
-		}
+	// Cross dissolve the two intermediate (source, dest) images by
+	//   using a weight clamped to the range [0.0, 1.0].
+	destWeight := clampUnit(nominalTimeConversion(clampUnit(t)))
+	return CrossDissolve([]image.Image{intermedSourceImage, intermedDestImage}, []float64{1 - destWeight, destWeight})
+}
 
-		// Auxiliary to intermediate, stretching vertically
-		sourceAuxSplines, nSplinesAuxSource, err = auxGridSource.allCubicCatmullRomSplines(false, 0.5, startBounds.Max.X-startBounds.Min.X)
-		if err != nil {
-			return nil, err
-		}
-		destAuxSplines, nSplinesDestSource, err = auxGridDest.allCubicCatmullRomSplines(false, 0.5, startBounds.Max.X-startBounds.Min.X)
-		if err != nil {
-			return nil, err
-		}
-		intermedSplines, nIntermedSplines, err := intermedGrid.allCubicCatmullRomSplines(false, 0.5, startBounds.Max.X-startBounds.Min.X)
-		if nIntermedSplines != nSplinesAuxSource {
-			return nil, errors.New("Auxilary grid and intermediate grid do not have the same number of splines.")
+// AverageWarp performs the geometric half of an N-way morph, such as building an
+// "average" composite of several images aligned on landmarks. Each grid pairs the
+// first image with one of the others: grids[i] holds the points of images[0] as
+// its start points and the points of images[i+1] as its destination points. All
+// grids must share the same topology and the same start points. The weighted
+// average of the N point sets is computed and every image is warped onto it. The
+// weights are normalized by their sum, so the warped images may be blended by
+// passing them to CrossDissolve along with weights that sum to one. The line model
+// and line ends of the first grid are used for every image.
+func AverageWarp(images []image.Image, grids []*MorphGrid, weights []float64) ([]image.Image, error) {
+	nImages := len(images)
+	if nImages != len(weights) {
+		return nil, errors.New("AverageWarp: Number of images does not match the number of weights")
+	}
+	if nImages <= 1 {
+		return nil, errors.New("AverageWarp: Two or more images must be provided")
+	}
+	if len(grids) != nImages-1 {
+		return nil, errors.New("AverageWarp: Exactly one grid must be provided for every image after the first")
+	}
+	bounds := images[0].Bounds()
+	for i := 1; i < nImages; i++ {
+		if !bounds.Eq(images[i].Bounds()) {
+			return nil, errors.New("AverageWarp: Image bounds do not match")
 		}
-
-		err = stretchPixelsVertically(startBounds.Min.X, startBounds.Max.X, sourceAuxSplines, intermedSplines, auxSourceImage, intermedSourceImage)
-		if err != nil {
-			return nil, err
+	}
+	imageGrids := make([]*float64CoordinateGrid, 0, nImages)
+	imageGrids = append(imageGrids, grids[0].start)
+	for i := 0; i < len(grids); i++ {
+		if !grids[i].start.equals(grids[0].start) {
+			return nil, errors.New("AverageWarp: Start points of grid " + strconv.Itoa(i) + " do not match those of grid 0")
 		}
-		err = stretchPixelsVertically(startBounds.Min.X, startBounds.Max.X, destAuxSplines, intermedSplines, auxDestImage, intermedDestImage)
+		imageGrids = append(imageGrids, grids[i].dest)
+	}
+	averageGrid, err := weightedAverageGrid(imageGrids, weights)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]image.Image, 0, nImages)
+	for i := 0; i < nImages; i++ {
+		warped, err := warpToGrid(images[i], imageGrids[i], averageGrid, grids[0].style)
 		if err != nil {
 			return nil, err
 		}
-
-		// Cross dissolve the two intermediate (source, dest) images by
-		//   using a weight (weight depends on i).
-		results[i-1], err = CrossDissolve([]image.Image{intermedSourceImage, intermedDestImage}, []float64{nominalTimeConversion(baseTimeFrac), 1 - nominalTimeConversion(baseTimeFrac)})
+		results = append(results, warped)
 	}
 	return results, nil
 }
@@ -155,7 +189,77 @@ func CrossDissolve(dissolving []image.Image, weights []float64) (image.Image, er
 	return result, nil
 }
 
-func stretchPixelsHorizontally(yStart, yEnd int, originalSplines, auxSplines []*parametricLineFloat64, start image.Image, final *image.RGBA64) error {
+// warpToGrid resamples an image so that the points of sourceGrid are moved onto the
+// homogulous points of targetGrid. It is a two-pass mesh warp: the image is first
+// stretched horizontally onto an auxilary grid, then vertically onto the target.
+// The grid lines are drawn with the given line style.
+func warpToGrid(img image.Image, sourceGrid, targetGrid *float64CoordinateGrid, style lineStyle) (*image.RGBA64, error) {
+	bounds := img.Bounds()
+	auxGrid, err := auxilaryGrid(sourceGrid, targetGrid)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate the spline for each vertical line in both the source and
+	//   auxilary grids, then stretch horizontally
+	sourceCurves, err := sourceGrid.allCurves(true, style)
+	if err != nil {
+		return nil, err
+	}
+	auxCurves, err := auxGrid.allCurves(true, style)
+	if err != nil {
+		return nil, err
+	}
+	if len(sourceCurves) != len(auxCurves) {
+		return nil, errors.New("warpToGrid: Source grid and auxilary grid do not have the same number of splines.")
+	}
+	auxImage := image.NewRGBA64(bounds)
+	err = stretchPixelsHorizontally(bounds.Min.Y, bounds.Max.Y, sourceCurves, auxCurves, img, auxImage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Auxiliary to target, stretching vertically
+	auxCurves, err = auxGrid.allCurves(false, style)
+	if err != nil {
+		return nil, err
+	}
+	targetCurves, err := targetGrid.allCurves(false, style)
+	if err != nil {
+		return nil, err
+	}
+	if len(targetCurves) != len(auxCurves) {
+		return nil, errors.New("warpToGrid: Auxilary grid and target grid do not have the same number of splines.")
+	}
+	result := image.NewRGBA64(bounds)
+	err = stretchPixelsVertically(bounds.Min.X, bounds.Max.X, auxCurves, targetCurves, auxImage, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// auxilaryGrid builds the grid between the two passes of the mesh warp, whose points
+// have the x of the source grid and the y of the target grid.
+func auxilaryGrid(sourceGrid, targetGrid *float64CoordinateGrid) (*float64CoordinateGrid, error) {
+	auxGrid := newFloat64CoordinateGrid()
+	for x := 0; x < targetGrid.verticalGridlineLen(); x++ {
+		for y := 0; y < targetGrid.horizontalGridlineLen(); y++ {
+			targetPt, err := targetGrid.point(y, x)
+			if err != nil {
+				continue
+			}
+			sourcePt, err := sourceGrid.point(y, x)
+			if err != nil {
+				return nil, err
+			}
+			auxGrid.addPoint(y, x, Float64Point{sourcePt.X, targetPt.Y})
+		}
+	}
+	return auxGrid, nil
+}
+
+func stretchPixelsHorizontally(yStart, yEnd int, originalSplines, auxSplines []axisCurve, start image.Image, final *image.RGBA64) error {
 	nSplines := len(originalSplines)
 	if nSplines != len(auxSplines) {
 		return errors.New("stretchPixelsHorizontally: Spline count does not match between start and final images")
@@ -179,7 +283,7 @@ func stretchPixelsHorizontally(yStart, yEnd int, originalSplines, auxSplines []*
 				return err
 			}
 			if len(origStart) != 1 || len(origEnd) != 1 || len(destStart) != 1 || len(destEnd) != 1 {
-				return errors.New("stretchPixelsHorizontally: Invalid spline length (folds back on itself, or no length)")
+				return errors.New("stretchPixelsHorizontally: Invalid spline length (folds back on itself, or no length) between vertical splines " + strconv.Itoa(iSpline) + " and " + strconv.Itoa(iSpline+1) + " at y=" + strconv.Itoa(y) + "; MorphGrid.Validate can locate the offending grid line")
 			}
 			mergePixelsInLine(true, y, iSpline != 0, iSpline != nSplines-1, origStart[0].X, origEnd[0].X, destStart[0].X, destEnd[0].X, start, final)
 		}
@@ -187,7 +291,7 @@ func stretchPixelsHorizontally(yStart, yEnd int, originalSplines, auxSplines []*
 	return nil
 }
 
-func stretchPixelsVertically(xStart, xEnd int, originalSplines, auxSplines []*parametricLineFloat64, start image.Image, final *image.RGBA64) error {
+func stretchPixelsVertically(xStart, xEnd int, originalSplines, auxSplines []axisCurve, start image.Image, final *image.RGBA64) error {
 	nSplines := len(originalSplines)
 	if nSplines != len(auxSplines) {
 		return errors.New("stretchPixelsVertically: Spline count does not match between start and final images")
@@ -211,7 +315,7 @@ func stretchPixelsVertically(xStart, xEnd int, originalSplines, auxSplines []*pa
 				return err
 			}
 			if len(origStart) > 1 || len(origEnd) > 1 || len(destStart) > 1 || len(destEnd) > 1 {
-				return errors.New("stretchPixelsVertically: Spline folds back on itself")
+				return errors.New("stretchPixelsVertically: Spline folds back on itself between horizontal splines " + strconv.Itoa(iSpline) + " and " + strconv.Itoa(iSpline+1) + " at x=" + strconv.Itoa(x) + "; MorphGrid.Validate can locate the offending grid line")
 			}
 			mergePixelsInLine(false, x, iSpline != 0, iSpline != nSplines-1, origStart[0].Y, origEnd[0].Y, destStart[0].Y, destEnd[0].Y, start, final)
 		}
@@ -224,18 +328,14 @@ func mergePixelsInLine(horizontally bool, line int, fadeStartPixel, fadeEndPixel
 	pixelOrigSnapEnd := int(math.Floor(origEnd)) + 1
 	var origColor color.Color
 	lastColoredDestPixel := int(math.Floor(destStart))
-	fmt.Printf("**mergePixelsInLine**\norigStart=%v, origEnd=%v, destStart=%v, destEnd=%v\n", origStart, origEnd, destStart, destEnd)
 	if !fadeStartPixel {
 		lastColoredDestPixel--
 	}
 	for iOrig := pixelOrigSnapStart; iOrig <= pixelOrigSnapEnd; iOrig++ {
-		fmt.Printf("iOrig=%v\n", iOrig)
 		if horizontally {
 			origColor = original.At(iOrig-1, line)
-			fmt.Printf("(%v, %v) origColor: %v\n", (iOrig - 1), line, origColor)
 		} else {
 			origColor = original.At(line, iOrig-1)
-			fmt.Printf("(%v, %v) origColor: %v\n", line, (iOrig - 1), origColor)
 		}
 
 		pct := (math.Min(float64(iOrig), origEnd) - origStart) / (origEnd - origStart)
@@ -250,7 +350,6 @@ func mergePixelsInLine(horizontally bool, line int, fadeStartPixel, fadeEndPixel
 			iEndDest := int(math.Floor(pct*(destEnd-destStart) + destStart))
 			iStartDest := int(math.Floor(pct*(destEnd-destStart) + destStart - wDest))
 			wDestFrac := 1 - (pct*(destEnd-destStart) + destStart - wDest - float64(iStartDest))
-			fmt.Printf("wDest=%v, iEndDest=%v, iStartDest=%v, wDestFrac=%v\n", wDest, iEndDest, iStartDest, wDestFrac)
 			for iDest := iStartDest; iDest <= iEndDest; iDest++ {
 				if iDest == iEndDest && iStartDest != iEndDest {
 					wDestFrac = pct*(destEnd-destStart) + destStart - float64(iEndDest)
@@ -259,23 +358,22 @@ func mergePixelsInLine(horizontally bool, line int, fadeStartPixel, fadeEndPixel
 					if iDest > lastColoredDestPixel && (!fadeEndPixel || (fadeEndPixel && iOrig != pixelOrigSnapEnd)) {
 						if horizontally {
 							dest.Set(iDest, line, weightColor(origColor, wDestFrac))
-							fmt.Printf("(%v, %v) destColor unadded (weight=%v): %v\n", iDest, line, wDestFrac, weightColor(origColor, wDestFrac))
 						} else {
 							dest.Set(line, iDest, weightColor(origColor, wDestFrac))
-							fmt.Printf("(%v, %v) destColor unadded (weight=%v): %v\n", line, iDest, wDestFrac, weightColor(origColor, wDestFrac))
 						}
 						lastColoredDestPixel = iDest
 					} else {
 						pastColor := dest.At(iDest, line)
+						if !horizontally {
+							pastColor = dest.At(line, iDest)
+						}
 						if iDest > lastColoredDestPixel && fadeEndPixel && iOrig == pixelOrigSnapEnd {
 							lastColoredDestPixel = iDest
 						}
 						if horizontally {
 							dest.Set(iDest, line, addColors(pastColor, weightColor(origColor, wDestFrac)))
-							fmt.Printf("(%v, %v) destColor added (weight=%v): %v\n", iDest, line, wDestFrac, addColors(pastColor, weightColor(origColor, wDestFrac)))
 						} else {
 							dest.Set(line, iDest, addColors(pastColor, weightColor(origColor, wDestFrac)))
-							fmt.Printf("(%v, %v) destColor added (weight=%v): %v\n", line, iDest, wDestFrac, addColors(pastColor, weightColor(origColor, wDestFrac)))
 						}
 					}
 				}
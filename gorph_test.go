@@ -5,6 +5,7 @@ import (
 	"image/color"
 	"image/png"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -124,6 +125,22 @@ func TestMergePixelsInHorizontalLine(t *testing.T) {
 	AssertEqualsUint32(t, a, 0xffff)
 }
 
+func TestMergePixelsInVerticalLine(t *testing.T) {
+	n := 4
+	test := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{n, n}})
+	test.Set(2, 0, color.RGBA64{0xffff, 0, 0, 0xffff})
+	test.Set(2, 1, color.RGBA64{0, 0, 0xffff, 0xffff})
+	testTwo := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{n, n}})
+	// The transposed pixel must not leak into the merged column
+	testTwo.Set(1, 2, color.RGBA64{0, 0xffff, 0, 0xffff})
+	mergePixelsInLine(false, 2, false, false, 0.0, 2.0, 0.0, 3.0, test, testTwo)
+	r, g, b, a := testTwo.At(2, 1).RGBA()
+	AssertEqualsUint32(t, r, 0x8000)
+	AssertEqualsUint32(t, g, 0)
+	AssertEqualsUint32(t, b, 0x8000)
+	AssertEqualsUint32(t, a, 0xffff)
+}
+
 func TestStretchHorizontally(t *testing.T) {
 	width := 4
 	height := 4
@@ -145,12 +162,26 @@ func TestStretchHorizontally(t *testing.T) {
 	mGrid.AddPoints(1, 2, image.Point{width, 2}, image.Point{width, 2})
 	mGrid.AddPoints(0, 1, image.Point{2, 0}, image.Point{3, 0})
 	mGrid.AddPoints(2, 1, image.Point{2, height}, image.Point{3, height})
-	start, end, nSplines, err := mGrid.allCubicCatmullRomSplines(true, 0.5, 5)
-	AssertEqualsInt(t, nSplines, 3)
+	start, err := mGrid.start.allCurves(true, mGrid.style)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	end, err := mGrid.dest.allCurves(true, mGrid.style)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(start), 3)
 	err = stretchPixelsHorizontally(0, height, start, end, test, testTwo)
 	if err != nil {
 		t.Fatal(err.Error())
 	}
+	// Every row was looked up in the index of the splines' y coordinates
+	for _, curve := range append(start, end...) {
+		spline := curve.(*CubicSpline)
+		if spline.yIndex.rising == nil || spline.xIndex.rising != nil {
+			t.Error("Stretch did not query the y index of the splines")
+		}
+	}
 	AssertEqualsImageColor(t, color.RGBA64{0, 0xfffd, 0, 0xffff}, testTwo.At(0, 0), "pixel (0,0)")
 	AssertEqualsImageColor(t, color.RGBA64{0x8000, 0xfffe, 0, 0xffff}, testTwo.At(1, 0), "pixel (1,0)")
 	AssertEqualsImageColor(t, color.RGBA64{0xffff, 0xfffd, 0, 0xffff}, testTwo.At(2, 0), "pixel (2,0)")
@@ -317,3 +348,190 @@ func TestInterpolateColorsEqual(t *testing.T) {
 	AssertEqualsUint32(t, b, 0x2000)
 	AssertEqualsUint32(t, a, 0x1000)
 }
+
+func squareMorphGrid(width, height int, destCenter image.Point) *MorphGrid {
+	mGrid := NewMorphGrid()
+	for h := 0; h < 3; h++ {
+		for v := 0; v < 3; v++ {
+			pt := image.Point{v * width / 2, h * height / 2}
+			if h == 1 && v == 1 {
+				mGrid.AddPoints(h, v, pt, destCenter)
+			} else {
+				mGrid.AddPoints(h, v, pt, pt)
+			}
+		}
+	}
+	return mGrid
+}
+
+func TestMorphFrameCount(t *testing.T) {
+	width := 8
+	height := 8
+	start := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	dest := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	mGrid := squareMorphGrid(width, height, image.Point{5, 5})
+	results, err := Morph(2, start, dest, *mGrid, LinearInterpolationImagePoints, func(t float64) float64 { return t })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(results), 2, "Number of morphs incorrect")
+}
+
+func TestAverageWarp(t *testing.T) {
+	width := 16
+	height := 16
+	images := make([]image.Image, 3)
+	for i := range images {
+		images[i] = gradientImage(width, height)
+	}
+	grids := []*MorphGrid{squareMorphGrid(width, height, image.Point{12, 10}), squareMorphGrid(width, height, image.Point{4, 6})}
+	results, err := AverageWarp(images, grids, []float64{1, 2, 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(results), 3, "Number of warped images incorrect")
+	// Only the centers differ, and they average to 0.25*(8,8) + 0.5*(12,10) + 0.25*(4,6)
+	average := newFloat64CoordinateGrid()
+	for _, index := range grids[0].Intersections() {
+		pt, _, _ := grids[0].Float64Points(index.HorizLine, index.VertLine)
+		if index.HorizLine == 1 && index.VertLine == 1 {
+			pt = Float64Point{9, 8.5}
+		}
+		average.addPoint(index.HorizLine, index.VertLine, pt)
+	}
+	sources := []*float64CoordinateGrid{grids[0].start, grids[0].dest, grids[1].dest}
+	for i := range results {
+		expected, err := warpToGrid(images[i], sources[i], average, grids[0].style)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !results[i].Bounds().Eq(expected.Bounds()) {
+			t.Fatal("Warped image bounds do not match")
+		}
+		for x := 0; x < width; x++ {
+			for y := 0; y < height; y++ {
+				AssertEqualsImageColor(t, results[i].At(x, y), expected.At(x, y), "Image "+strconv.Itoa(i)+" not warped onto the average grid")
+			}
+		}
+	}
+}
+
+func TestAverageWarpMismatchedStart(t *testing.T) {
+	width := 8
+	height := 8
+	images := make([]image.Image, 3)
+	for i := range images {
+		images[i] = image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	}
+	other := squareMorphGrid(width, height, image.Point{3, 3})
+	err := other.RemovePoints(1, 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	other.AddPoints(1, 1, image.Point{3, 3}, image.Point{3, 3})
+	grids := []*MorphGrid{squareMorphGrid(width, height, image.Point{5, 5}), other}
+	_, err = AverageWarp(images, grids, []float64{1, 1, 1})
+	if err == nil {
+		t.Error("Expected error for grids with differing start points")
+	}
+}
+
+func TestMorphFrameExtrapolatedColorWeights(t *testing.T) {
+	width := 8
+	height := 8
+	start := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	dest := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	for i := 0; i < width; i++ {
+		for j := 0; j < height; j++ {
+			start.Set(i, j, color.RGBA64{0xffff, 0, 0, 0xffff})
+			dest.Set(i, j, color.RGBA64{0, 0, 0xffff, 0xffff})
+		}
+	}
+	mGrid := squareMorphGrid(width, height, image.Point{5, 5})
+	result, err := MorphFrame(-0.3, start, dest, *mGrid, LinearInterpolationImagePoints, func(t float64) float64 { return t })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	_, _, b, _ := result.At(1, 1).RGBA()
+	AssertEqualsUint32(t, b, 0, "Destination color leaked into extrapolated start frame")
+	result, err = MorphFrame(1.3, start, dest, *mGrid, LinearInterpolationImagePoints, func(t float64) float64 { return t })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	r, _, _, _ := result.At(1, 1).RGBA()
+	AssertEqualsUint32(t, r, 0, "Start color leaked into extrapolated destination frame")
+}
+
+func TestMorphFrameExtrapolatedCrossing(t *testing.T) {
+	width := 8
+	height := 8
+	start := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	dest := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	mGrid := squareMorphGrid(width, height, image.Point{7, 7})
+	_, err := MorphFrame(2.5, start, dest, *mGrid, LinearInterpolationImagePoints, func(t float64) float64 { return t })
+	if err == nil {
+		t.Error("Expected extrapolated grid lines to cross")
+	}
+}
+
+func TestMorphReferenceBounds(t *testing.T) {
+	width := 8
+	height := 8
+	start := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	dest := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	mGrid := squareMorphGrid(width/2, height/2, image.Point{3, 3})
+	mGrid.SetReferenceBounds(image.Rect(0, 0, width/2, height/2))
+	results, err := Morph(1, start, dest, *mGrid, LinearInterpolationImagePoints, func(t float64) float64 { return t })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(results), 1, "Number of morphs incorrect")
+}
+
+func TestMorphNormalizedGrid(t *testing.T) {
+	width := 16
+	height := 12
+	start := gradientImage(width, height)
+	dest := gradientImage(width, height)
+	pixelGrid := squareMorphGrid(width, height, image.Point{10, 4})
+	normalGrid := NewNormalizedMorphGrid()
+	normalize := ScaleAffine(1/float64(width), 1/float64(height))
+	for _, index := range pixelGrid.Intersections() {
+		startPt, destPt, _ := pixelGrid.Float64Points(index.HorizLine, index.VertLine)
+		normalGrid.AddFloat64Points(index.HorizLine, index.VertLine, normalize.Apply(startPt), normalize.Apply(destPt))
+	}
+	linear := func(t float64) float64 { return t }
+	want, err := Morph(1, start, dest, *pixelGrid, LinearInterpolationImagePoints, linear)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	got, err := Morph(1, start, dest, *normalGrid, LinearInterpolationImagePoints, linear)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			AssertEqualsImageColor(t, want[0].At(x, y), got[0].At(x, y), "Normalized grid morphs differently")
+		}
+	}
+}
+
+func TestMorphFrameMonotoneCubic(t *testing.T) {
+	width := 8
+	height := 8
+	start := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	dest := image.NewRGBA64(image.Rectangle{image.Point{0, 0}, image.Point{width, height}})
+	for i := 0; i < width; i++ {
+		for j := 0; j < height; j++ {
+			start.Set(i, j, color.RGBA64{0xffff, 0, 0, 0xffff})
+			dest.Set(i, j, color.RGBA64{0xffff, 0, 0, 0xffff})
+		}
+	}
+	mGrid := squareMorphGrid(width, height, image.Point{6, 6})
+	mGrid.SetLineModel(LineMonotoneCubic)
+	result, err := MorphFrame(0.5, start, dest, *mGrid, LinearInterpolationImagePoints, func(t float64) float64 { return t })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsImageColor(t, result.At(4, 4), color.RGBA64{0xffff, 0, 0, 0xffff}, "Warped color incorrect")
+}
@@ -2,16 +2,17 @@ package gorph
 
 import (
 	"errors"
-	"fmt"
 	"strconv"
 )
 
 type parametricLineFloat64 struct {
 	parametricPoints []Float64Point
+	xIndex           *lineSegmentIndex
+	yIndex           *lineSegmentIndex
 }
 
 func newParametricLineFloat64() *parametricLineFloat64 {
-	return &parametricLineFloat64{nil}
+	return &parametricLineFloat64{nil, nil, nil}
 }
 
 func (p *parametricLineFloat64) Len() int {
@@ -20,6 +21,7 @@ func (p *parametricLineFloat64) Len() int {
 
 func (p *parametricLineFloat64) AddPoint(pt Float64Point) int {
 	p.parametricPoints = append(p.parametricPoints, pt)
+	p.xIndex, p.yIndex = nil, nil
 	return len(p.parametricPoints) - 1
 }
 
@@ -36,6 +38,7 @@ func (p *parametricLineFloat64) RemovePoint(index int) error {
 	copy(p.parametricPoints[index:], p.parametricPoints[index+1:])
 	p.parametricPoints[len(p.parametricPoints)-1] = Float64Point{0, 0}
 	p.parametricPoints = p.parametricPoints[:len(p.parametricPoints)-1]
+	p.xIndex, p.yIndex = nil, nil
 	return nil
 }
 
@@ -50,45 +53,73 @@ func (p *parametricLineFloat64) HasPoints() bool {
 	return len(p.parametricPoints) > 0
 }
 
+// InterpolatePointsAtX finds the points at which the line rises through the given x
+// value, by linearly interpolating every segment whose x increases past it. The
+// last point is included if its x equals the value. Lookups use an index of the
+// line's rising segments, built on first use.
 func (p *parametricLineFloat64) InterpolatePointsAtX(xValue float64) (points []Float64Point, err error) {
 	if p.Len() < 2 {
 		return nil, errors.New("InterpolatePointsAtX: Line has fewer than 2 points.")
 	}
-	points = nil
-	err = nil
-	for i := 1; i < len(p.parametricPoints); i++ {
-		if p.parametricPoints[i].X > xValue && p.parametricPoints[i-1].X <= xValue {
-			points = append(points, LinearInterpolation(p.parametricPoints[i-1], p.parametricPoints[i], (xValue-p.parametricPoints[i-1].X)/(p.parametricPoints[i].X-p.parametricPoints[i-1].X)))
-			fmt.Printf("InterpolatePointsAtX, appended: (%v,%v)\n", points[len(points)-1].X, points[len(points)-1].Y)
-		}
-	}
-	if p.parametricPoints[len(p.parametricPoints)-1].X == xValue {
-		points = append(points, p.parametricPoints[len(p.parametricPoints)-1])
+	if p.xIndex == nil {
+		p.xIndex = newLineSegmentIndex(p.parametricPoints, func(pt Float64Point) float64 { return pt.X })
 	}
+	points = p.interpolateIndexed(p.xIndex, xValue)
 	if len(points) == 0 {
 		err = errors.New("InterpolatePointsAtX: No points interpolated for value = " + strconv.FormatFloat(xValue, 'g', 8, 64))
 	}
 	return
 }
 
+// InterpolatePointsAtY finds the points at which the line rises through the given y
+// value, like InterpolatePointsAtX.
 func (p *parametricLineFloat64) InterpolatePointsAtY(yValue float64) (points []Float64Point, err error) {
 	if p.Len() < 2 {
 		return nil, errors.New("InterpolatePointsAtY: Line has fewer than 2 points.")
 	}
-	points = nil
-	err = nil
-	for i := 1; i < len(p.parametricPoints); i++ {
-		if p.parametricPoints[i].Y > yValue && p.parametricPoints[i-1].Y <= yValue {
-			fmt.Printf("Linearly interpolating %v and %v over %v\n", p.parametricPoints[i-1], p.parametricPoints[i], (yValue-p.parametricPoints[i-1].Y)/(p.parametricPoints[i].Y-p.parametricPoints[i-1].Y))
-			points = append(points, LinearInterpolation(p.parametricPoints[i-1], p.parametricPoints[i], (yValue-p.parametricPoints[i-1].Y)/(p.parametricPoints[i].Y-p.parametricPoints[i-1].Y)))
-			fmt.Printf("InterpolatePointsAtY, appended for i=%v: (%v,%v)\n", i, points[len(points)-1].X, points[len(points)-1].Y)
-		}
-	}
-	if p.parametricPoints[len(p.parametricPoints)-1].Y == yValue {
-		points = append(points, p.parametricPoints[len(p.parametricPoints)-1])
+	if p.yIndex == nil {
+		p.yIndex = newLineSegmentIndex(p.parametricPoints, func(pt Float64Point) float64 { return pt.Y })
 	}
+	points = p.interpolateIndexed(p.yIndex, yValue)
 	if len(points) == 0 {
 		err = errors.New("InterpolatePointsAtY: No points interpolated for value = " + strconv.FormatFloat(yValue, 'g', 8, 64))
 	}
 	return
 }
+
+func (p *parametricLineFloat64) interpolateIndexed(index *lineSegmentIndex, value float64) (points []Float64Point) {
+	var buffer [4]int
+	for _, piece := range index.rising.appendPiecesAt(buffer[:0], value) {
+		i := index.segments[piece]
+		start := index.values[i-1]
+		points = append(points, LinearInterpolation(p.parametricPoints[i-1], p.parametricPoints[i], (value-start)/(index.values[i]-start)))
+	}
+	if index.values[len(index.values)-1] == value {
+		points = append(points, p.parametricPoints[len(p.parametricPoints)-1])
+	}
+	return
+}
+
+// lineSegmentIndex indexes one coordinate of a line's points. Every segment from
+// point i-1 to point i along which the coordinate rises is a piece of the rising
+// index, and segments holds the i of every piece.
+type lineSegmentIndex struct {
+	values   []float64
+	segments []int
+	rising   *risingIntervalIndex
+}
+
+func newLineSegmentIndex(pts []Float64Point, coordinate func(Float64Point) float64) *lineSegmentIndex {
+	index := &lineSegmentIndex{make([]float64, len(pts)), nil, nil}
+	var lo, hi []float64
+	for i, pt := range pts {
+		index.values[i] = coordinate(pt)
+		if i > 0 && index.values[i] > index.values[i-1] {
+			index.segments = append(index.segments, i)
+			lo = append(lo, index.values[i-1])
+			hi = append(hi, index.values[i])
+		}
+	}
+	index.rising = newRisingIntervalIndex(lo, hi)
+	return index
+}
@@ -0,0 +1,71 @@
+package gorph
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// scanPointsAtY is the linear scan the index replaces.
+func scanPointsAtY(pts []Float64Point, yValue float64) (points []Float64Point) {
+	for i := 1; i < len(pts); i++ {
+		if pts[i].Y > yValue && pts[i-1].Y <= yValue {
+			points = append(points, LinearInterpolation(pts[i-1], pts[i], (yValue-pts[i-1].Y)/(pts[i].Y-pts[i-1].Y)))
+		}
+	}
+	if pts[len(pts)-1].Y == yValue {
+		points = append(points, pts[len(pts)-1])
+	}
+	return
+}
+
+func TestParametricLineInterpolatePointsAtYMatchesScan(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 50; trial++ {
+		line := newParametricLineFloat64()
+		y := 0.0
+		for i := 0; i < 40; i++ {
+			// Mostly rising, with occasional folds and flat steps
+			y += float64(r.Intn(7) - 1)
+			line.AddPoint(Float64Point{float64(i), y})
+		}
+		for value := -5.0; value <= 200; value += 0.5 {
+			expected := scanPointsAtY(line.parametricPoints, value)
+			result, err := line.InterpolatePointsAtY(value)
+			if len(expected) == 0 {
+				if err == nil {
+					t.Fatal("Expected error for value", value)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			AssertEqualsInt(t, len(result), len(expected), "Wrong number of points")
+			for i := range expected {
+				AssertEqualsFloat64Point(t, result[i], expected[i], "Indexed lookup differs from scan")
+			}
+		}
+	}
+}
+
+func TestParametricLineIndexInvalidated(t *testing.T) {
+	line := newParametricLineFloat64()
+	line.AddPoints([]Float64Point{{0, 0}, {1, 10}, {2, 20}})
+	pts, err := line.InterpolatePointsAtX(1.5)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64Point(t, pts[0], Float64Point{1.5, 15}, "Incorrect point")
+	line.AddPoints([]Float64Point{{1, 30}, {3, 40}})
+	pts, err = line.InterpolatePointsAtX(1.5)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsInt(t, len(pts), 2, "Index not rebuilt after adding points")
+	_ = line.RemovePoint(3)
+	pts, err = line.InterpolatePointsAtX(2.5)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	AssertEqualsFloat64Point(t, pts[0], Float64Point{2.5, 30}, "Index not rebuilt after removing a point")
+}
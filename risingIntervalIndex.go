@@ -0,0 +1,100 @@
+package gorph
+
+import (
+	"math"
+	"sort"
+)
+
+// risingIntervalIndex finds the pieces of a line along which one coordinate rises
+// through a given value. Every piece is the half-open range [lo, hi) the coordinate
+// sweeps over a stretch of the line, and pieces are numbered in order along the
+// line. The pieces are kept in a centered interval tree of depth O(log n), so a
+// lookup costs O(log n) plus the number of pieces found, however often the line
+// folds back on itself.
+type risingIntervalIndex struct {
+	lo   []float64
+	hi   []float64
+	root *risingIntervalNode
+}
+
+// risingIntervalNode holds the pieces whose range contains its center, sorted both
+// by increasing lo and by decreasing hi, and the subtrees of the pieces wholly below
+// and wholly above the center.
+type risingIntervalNode struct {
+	center float64
+	byLo   []int
+	byHi   []int
+	below  *risingIntervalNode
+	above  *risingIntervalNode
+}
+
+// newRisingIntervalIndex indexes the pieces [lo[i], hi[i]), each of which must have
+// lo[i] < hi[i].
+func newRisingIntervalIndex(lo, hi []float64) *risingIntervalIndex {
+	index := &risingIntervalIndex{lo, hi, nil}
+	pieces := make([]int, len(lo))
+	for i := range pieces {
+		pieces[i] = i
+	}
+	index.root = index.build(pieces)
+	return index
+}
+
+func (index *risingIntervalIndex) build(pieces []int) *risingIntervalNode {
+	if len(pieces) == 0 {
+		return nil
+	}
+	sort.SliceStable(pieces, func(a, b int) bool { return index.lo[pieces[a]] < index.lo[pieces[b]] })
+	// Centering on the lo of the middle piece keeps that piece in the node, and
+	// leaves at most half of the pieces on either side
+	node := &risingIntervalNode{center: index.lo[pieces[len(pieces)/2]]}
+	var below, above []int
+	for _, i := range pieces {
+		if index.hi[i] <= node.center {
+			below = append(below, i)
+		} else if index.lo[i] > node.center {
+			above = append(above, i)
+		} else {
+			node.byLo = append(node.byLo, i)
+		}
+	}
+	node.byHi = append([]int(nil), node.byLo...)
+	sort.SliceStable(node.byHi, func(a, b int) bool { return index.hi[node.byHi[a]] > index.hi[node.byHi[b]] })
+	node.below = index.build(below)
+	node.above = index.build(above)
+	return node
+}
+
+// appendPiecesAt appends the number of every piece with lo <= value < hi to dst, in
+// order along the line.
+func (index *risingIntervalIndex) appendPiecesAt(dst []int, value float64) []int {
+	if math.IsNaN(value) {
+		return dst
+	}
+	start := len(dst)
+	for node := index.root; node != nil; {
+		if value < node.center {
+			// Every piece of the node ends above value, so it holds value if it starts
+			// at or below it
+			for _, i := range node.byLo {
+				if index.lo[i] > value {
+					break
+				}
+				dst = append(dst, i)
+			}
+			node = node.below
+		} else {
+			for _, i := range node.byHi {
+				if index.hi[i] <= value {
+					break
+				}
+				dst = append(dst, i)
+			}
+			node = node.above
+		}
+	}
+	if len(dst)-start > 1 {
+		sort.Ints(dst[start:])
+	}
+	return dst
+}
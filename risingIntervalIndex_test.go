@@ -0,0 +1,55 @@
+package gorph
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func risingIntervalDepth(node *risingIntervalNode) int {
+	if node == nil {
+		return 0
+	}
+	below, above := risingIntervalDepth(node.below), risingIntervalDepth(node.above)
+	if below > above {
+		return below + 1
+	}
+	return above + 1
+}
+
+func TestRisingIntervalIndexMatchesScan(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 20; trial++ {
+		n := 1 + r.Intn(200)
+		lo := make([]float64, n)
+		hi := make([]float64, n)
+		for i := range lo {
+			lo[i] = float64(r.Intn(50))
+			hi[i] = lo[i] + 1 + float64(r.Intn(20))
+		}
+		index := newRisingIntervalIndex(lo, hi)
+		if depth := risingIntervalDepth(index.root); depth > int(math.Log2(float64(n)))+1 {
+			t.Fatal("Interval tree of", n, "pieces has depth", depth)
+		}
+		for value := -1.0; value <= 72; value += 0.5 {
+			var expected []int
+			for i := range lo {
+				if lo[i] <= value && value < hi[i] {
+					expected = append(expected, i)
+				}
+			}
+			result := index.appendPiecesAt(nil, value)
+			AssertEqualsInt(t, len(result), len(expected), "Wrong number of pieces")
+			for i := range expected {
+				AssertEqualsInt(t, result[i], expected[i], "Pieces differ from scan")
+			}
+		}
+	}
+}
+
+func TestRisingIntervalIndexEmpty(t *testing.T) {
+	index := newRisingIntervalIndex(nil, nil)
+	AssertEqualsInt(t, len(index.appendPiecesAt(nil, 0)), 0, "Empty index found pieces")
+	index = newRisingIntervalIndex([]float64{0}, []float64{1})
+	AssertEqualsInt(t, len(index.appendPiecesAt(nil, math.NaN())), 0, "NaN found in a piece")
+}